@@ -8,9 +8,13 @@ import (
 	"net"
 	"net/rpc"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 	"uk.ac.bris.cs/gameoflife/Distributed/Shared"
+	"uk.ac.bris.cs/gameoflife/logging"
+	"uk.ac.bris.cs/gameoflife/metrics"
+	"uk.ac.bris.cs/gameoflife/util"
 )
 
 //------------------GLOBAL VARIABLES AND APPLICABLE STRUCTS-------------------------
@@ -38,6 +42,24 @@ type pauseStruct struct {
 
 var paused pauseStruct
 
+//pauseChange is held while the workers are told to pause or unpause, so that p and n can't undo each other
+var pauseChange sync.Mutex
+
+type rateStruct struct {
+	turnsPerSecond int
+	lastTurn       time.Time
+	lock           sync.Mutex
+}
+
+var rate rateStruct
+
+type steppingStruct struct {
+	step bool
+	lock sync.Mutex
+}
+
+var stepping steppingStruct
+
 var workerChannelList = make([]chan [][]byte, WORKERS)
 
 var stripSizeList []int
@@ -46,6 +68,8 @@ var controller *rpc.Client
 
 var clientsPorts [4]string
 
+var brokerLog = logging.Component("broker")
+
 //------------------CONSTANTS-------------------------
 
 // WORKERS - Number of clients being used to run GoL
@@ -73,6 +97,38 @@ func changePaused() {
 	paused.lock.Unlock()
 }
 
+func changeRate(input int) {
+	rate.lock.Lock()
+	rate.turnsPerSecond = input
+	rate.lock.Unlock()
+}
+
+func changeStepping(input bool) {
+	stepping.lock.Lock()
+	stepping.step = input
+	stepping.lock.Unlock()
+}
+
+//Reports whether a step was asked for, clearing it so that it is only taken once
+func takeStep() bool {
+	stepping.lock.Lock()
+	var temp = stepping.step
+	stepping.step = false
+	stepping.lock.Unlock()
+	return temp
+}
+
+//Holds the next turn back until it is due at the target speed
+func waitForRate() {
+	rate.lock.Lock()
+	var delay = util.RateDelay(rate.lastTurn, rate.turnsPerSecond, time.Now())
+	rate.lock.Unlock()
+	time.Sleep(delay)
+	rate.lock.Lock()
+	rate.lastTurn = time.Now()
+	rate.lock.Unlock()
+}
+
 func getCurrentWorld() [][]byte {
 	currentWorld.lock.Lock()
 	var temp = currentWorld.world
@@ -99,7 +155,7 @@ type BrokerOperations struct{}
 //------------------INCOMING RPC CALLS-------------------------
 
 func (s *BrokerOperations) Hi(req Shared.Request, res *Shared.Response) (err error) {
-	fmt.Println("Hi")
+	brokerLog.Debug("Hi")
 	return
 }
 
@@ -109,26 +165,28 @@ func (s *BrokerOperations) GoLManager(req Shared.Request, res *Shared.Response)
 setback:
 	//fmt.Println("Pause: ", getPaused())
 	var turn int
-	fmt.Println(Clients)
 	if paused.pause {
-		fmt.Println("Continuing old")
+		brokerLog.Info("Resuming the game where it stopped")
 		paused.pause = !paused.pause
 		paused.lock.Unlock()
 		for i := 0; i < WORKERS; i++ {
 			req.Paused = false
 			j := HandleCallAndError(Clients[i], Shared.PauseHandler, &req, res, i, res)
-			fmt.Println("Rurning call print value: ", j)
-			fmt.Println("Didn't break here")
+			brokerLog.Debug("Unpaused a worker", "worker", i, "failed", j != 0)
 			if j != 0 {
-				fmt.Println("going to the start ")
+				brokerLog.Warn("A worker reconnected while resuming, starting again", "worker", i)
 				goto setback
 			}
 		}
 
 		turn = getCurrentTurn()
 	} else {
-		fmt.Println("I ain't paused yet")
+		brokerLog.Info("Starting a new game", "turns", req.Parameters.Turns)
 		turn = 0
+		//A broker that was shut down before this one may have left the workers paused
+		pauseChange.Lock()
+		setWorkersPaused(false)
+		pauseChange.Unlock()
 		changeCurrentWorld(req.World)
 	}
 	//waitGroup.Wait()
@@ -138,7 +196,6 @@ setback:
 		workerChannelList[j] = workerChannel
 	}
 	stripSizeList = distributeSliceSizes(req.Parameters)
-	fmt.Println(getCurrentTurn())
 	for i := turn; i < req.Parameters.Turns; i++ {
 		//fmt.Println("Entering for loop")
 		//We now do split the input world for each thread accordingly
@@ -157,13 +214,23 @@ setback:
 			var newWorld = mergeWorkerStrips(res.World, workerChannelList, stripSizeList)
 			changeCurrentTurn(i + 1)
 			changeCurrentWorld(newWorld)
+			turnsMetric.Inc()
+			turnRateMetric.Mark()
 		} else {
-			fmt.Println("Detected reconnection:")
+			brokerLog.Warn("A worker reconnected, running the turn again")
 			changePaused()
 			paused.lock.Lock()
 			goto setback //Jump back to the start if anything reconnects.
 		}
 		//fmt.Println(getCurrentTurn())
+		if takeStep() {
+			//Pause the workers again before the next turn starts, so that a step only runs one turn,
+			//unless p has resumed the game since
+			pauseChange.Lock()
+			setWorkersPaused(getPaused())
+			pauseChange.Unlock()
+		}
+		waitForRate()
 
 		//reportToController(req.Parameters, req.Events, getCurrentWorld(), newWorld)
 
@@ -171,7 +238,7 @@ setback:
 		paused.lock.Unlock()
 	}
 	res.World = getCurrentWorld()
-	fmt.Println("Returning info")
+	brokerLog.Info("Game finished")
 	return
 }
 
@@ -189,10 +256,9 @@ func (s *BrokerOperations) BrokerInfo(req Shared.Request, res *Shared.Response)
 //Called from the local controller to tell the AWS node to kill itself
 func (s *BrokerOperations) KYS(request Shared.Request, response *Shared.Response) (err error) {
 	for i := 0; i < WORKERS; i++ {
-		fmt.Println("Killing it", i)
+		brokerLog.Info("Killing a worker", "worker", i)
 		i := i
 		go func() { HandleCallAndError(Clients[i], Shared.SuicideHandler, &request, response, i, response) }()
-		fmt.Println("Killed it", i)
 	}
 	time.Sleep(1 * time.Second)
 	os.Exit(0)
@@ -202,16 +268,44 @@ func (s *BrokerOperations) KYS(request Shared.Request, response *Shared.Response
 // PauseManager :Handler whenever the user presses "p". Transmits Pause commands to Workers
 //If already paused then unpause, otherwise pause.
 func (s *BrokerOperations) PauseManager(request Shared.Request, response *Shared.Response) (err error) {
-	for i := 0; i < WORKERS; i++ {
-		i := i
-		request.Paused = !getPaused()
-		go func() { HandleCallAndError(Clients[i], Shared.PauseHandler, &request, response, i, response) }()
-	}
+	pauseChange.Lock()
+	defer pauseChange.Unlock()
 	changePaused()
-	fmt.Println()
+	setWorkersPaused(getPaused())
+	brokerLog.Info("Pause toggled", "paused", getPaused())
+	return
+}
+
+// RateManager :Handler whenever the user presses "+" or "-". Sets the target speed in turns per second,
+//where zero runs as fast as possible
+func (s *BrokerOperations) RateManager(request Shared.Request, response *Shared.Response) (err error) {
+	changeRate(request.TurnsPerSecond)
+	response.Turns = getCurrentTurn()
+	return
+}
+
+// StepManager :Handler whenever the user presses "n". If paused, lets the workers finish the turn they are paused on,
+//and they are paused again before the next one starts
+func (s *BrokerOperations) StepManager(request Shared.Request, response *Shared.Response) (err error) {
+	pauseChange.Lock()
+	defer pauseChange.Unlock()
+	if !getPaused() {
+		return
+	}
+	changeStepping(true)
+	setWorkersPaused(false)
+	response.Turns = getCurrentTurn()
 	return
 }
 
+//Sends a pause or unpause to every worker and waits for them all to take it. Workers already in that state ignore it.
+func setWorkersPaused(pause bool) {
+	var request = Shared.Request{Paused: pause}
+	for i := 0; i < WORKERS; i++ {
+		HandleCallAndError(Clients[i], Shared.PauseHandler, &request, new(Shared.Response), i, new(Shared.Response))
+	}
+}
+
 // BackgroundManager :Handler whenever the user presses "q"
 //	When the local controller is killed, then pause the node and then wait until a new local controller is created
 // This is a form of fault tolerance.
@@ -221,13 +315,75 @@ func (s *BrokerOperations) BackgroundManager(request Shared.Request, response *S
 		go func() { HandleCallAndError(Clients[i], Shared.PauseHandler, &request, response, i, response) }()
 	}
 	changePaused()
-	fmt.Println("Paused after kill: ", getPaused())
+	brokerLog.Info("The controller left, pausing until another connects", "paused", getPaused())
 	paused.lock.Lock()
 	return
 }
 
 //------------------SETUP FUNCTIONS-------------------------
 
+//Called from main when the broker is interrupted or terminated
+//Pauses the workers so that they stop part way through nothing, saves the current world, then lets the workers go
+//again before exiting, as a broker started after this one would otherwise find them paused
+func shutDown() {
+	brokerLog.Info("Shutting down, pausing the workers")
+	tellWorkersBriefly(true)
+
+	var world = getCurrentWorld()
+	if len(world) > 0 {
+		var turn = getCurrentTurn()
+		//Saved next to the controller's images, as the broker is run from its own directory like the controller
+		var filename = "../../out/broker-" + strconv.Itoa(len(world[0])) + "x" + strconv.Itoa(len(world)) + "x" +
+			strconv.Itoa(turn) + ".rle"
+		if saveError := saveWorld(filename, world, turn); saveError != nil {
+			brokerLog.Warn("Could not save the world", "file", filename, "error", saveError)
+		} else {
+			brokerLog.Info("Saved the world", "file", filename)
+		}
+	}
+
+	tellWorkersBriefly(false)
+	os.Exit(0)
+}
+
+//Helper function of shutDown
+//Sends a pause or unpause to every worker, not waiting long on a worker that has gone away
+func tellWorkersBriefly(pause bool) {
+	var request = Shared.Request{Paused: pause}
+	for i := 0; i < WORKERS; i++ {
+		if Clients[i] == nil {
+			continue
+		}
+		call := Clients[i].Go(Shared.PauseHandler, &request, new(Shared.Response), nil)
+		select {
+		case <-call.Done:
+		case <-time.After(time.Second):
+			brokerLog.Warn("A worker did not answer", "worker", i, "paused", pause)
+		}
+	}
+}
+
+//Helper function of shutDown
+//Writes the world as an RLE pattern, with the turns completed in a comment so that the run can be carried on from it
+func saveWorld(filename string, world [][]byte, turn int) error {
+	if mkdirError := os.MkdirAll("../../out", 0755); mkdirError != nil {
+		return mkdirError
+	}
+	file, createError := os.Create(filename)
+	if createError != nil {
+		return createError
+	}
+	if _, writeError := fmt.Fprintf(file, "#C Completed turns: %d\n", turn); writeError != nil {
+		file.Close()
+		return writeError
+	}
+	if writeError := util.WriteRLE(file, util.PatternFromWorld(world, "B3/S23")); writeError != nil {
+		file.Close()
+		return writeError
+	}
+	return file.Close()
+}
+
 //Sets up the clients for the workers/nodes, called from main
 //Hard coded for 4 workers, arbitrary ports
 func connectToWorkers() {
@@ -238,7 +394,7 @@ func connectToWorkers() {
 
 	//Initialize our clients
 	for i := 0; i < len(clientsPorts); i++ {
-		fmt.Println("Attempting to connect to : ", clientsPorts[i])
+		brokerLog.Info("Connecting to a worker", "worker", i, "address", clientsPorts[i])
 		clientsConnections[i] = Shared.HandleCreateClientAndError(clientsPorts[i])
 	}
 
@@ -248,7 +404,13 @@ func connectToWorkers() {
 //Main sets up a listener to listen for controller
 func main() {
 	pAddr := flag.String("port", "8030", "Port to listen on")
+	metricsAddr := flag.String("metrics", "", "Address to serve metrics on at /metrics, e.g. :9130. Off by default")
+	logFlags := logging.AddFlags()
 	flag.Parse()
+	logFlags.Setup("broker", "turn", logging.Lazy(func() interface{} { return getCurrentTurn() }))
+	if *metricsAddr != "" {
+		brokerLog.Info("Serving metrics", "url", "http://"+metrics.Serve(*metricsAddr)+"/metrics")
+	}
 	rand.Seed(time.Now().UnixNano())
 
 	Shared.HandleRegisterAndError(&BrokerOperations{})
@@ -261,6 +423,8 @@ func main() {
 	}(listener)
 
 	go connectToWorkers()
+	//Ctrl-C or a terminate signal saves where the game got to rather than losing it
+	util.OnShutdownSignal(shutDown)
 	//controller = Shared.HandleCreateClientAndError("127.0.0.1:8035")
 
 	rpc.Accept(listener)
@@ -1,15 +1,18 @@
 package main
 
 import (
-	"fmt"
 	"math"
 	"net/rpc"
+	"strconv"
 	"sync"
 	"time"
 	"uk.ac.bris.cs/gameoflife/Distributed/Shared"
+	"uk.ac.bris.cs/gameoflife/logging"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
+var rpcLog = logging.Component("rpc")
+
 func createRequestResponsePair(p Shared.Params, events chan<- Shared.Event) (Shared.Request, *Shared.Response) {
 
 	//Forms the request which contains the [][]byte version of the PGM file
@@ -21,7 +24,8 @@ func createRequestResponsePair(p Shared.Params, events chan<- Shared.Event) (Sha
 		CallAlive:   make(chan int, 1),
 		GetAlive:    make(chan int, 1),
 		GetTurn:     make(chan int, 1),
-		Paused:      !getPaused()}
+		Paused:      !getPaused(),
+		Turn:        getCurrentTurn()}
 	//There doesn't exist a response, but we will create a new one
 	response := new(Shared.Response)
 
@@ -122,21 +126,17 @@ reconnect:
 	//The difference seems to be random every call, so perhaps issues with response access?
 
 	if j == 1 {
-		fmt.Println("We disconnected here in the manager in Broker/helperfunctions.go function: manager \n" +
-			"We want to reconnect but don't know what to do ")
-		fmt.Println("Current state of the world at worker: ", clientNum, "- ", res.World)
+		rpcLog.Warn("A worker disconnected during its strip, sending it again", "worker", clientNum)
 		resendFlag = true
 
 		goto reconnect
 	}
 
 	if resendFlag {
-		fmt.Println("Detected reconnection from manager:")
+		rpcLog.Warn("A worker reconnected during the turn", "worker", clientNum)
 		brokerRes.Resend = true
 	}
-	if req.Parameters.ImageWidth == 16 && req.Parameters.Turns == 1 {
-		fmt.Println("\n", clientNum+1, res.World)
-	}
+	rpcLog.Debug("Strip worked on", "worker", clientNum, "rows", len(res.World))
 	return res.World
 }
 
@@ -149,7 +149,7 @@ func executeWorker(inputWorld [][]byte, workerChannelList []chan [][]byte, strip
 		workerNumber, imageHeight)
 	req.Parameters.ImageHeight = (stripSize) + BUFFER
 
-	fmt.Println(len(req.World))
+	rpcLog.Debug("Sending a strip", "worker", workerNumber, "rows", len(req.World))
 	workerChannelList[workerNumber] <- manager(req, res,
 		workerChannelList[workerNumber], workerNumber, brokerRes)
 	defer (*waitGroup).Done()
@@ -181,14 +181,14 @@ func HandleCreateClientAndError(serverPort string) *rpc.Client {
 	//Initial connection attempt
 	client, dialError := rpc.Dial("tcp", serverPort)
 
-	fmt.Println("dialerror: ", dialError)
+	rpcLog.Debug("Dialled", "address", serverPort, "error", dialError)
 
 pingLoop:
 	//Iterative solution
 	for {
 		//Busy waiting with 250ms ping
 		time.Sleep(250 * time.Millisecond)
-		fmt.Println("250ms ping")
+		rpcLog.Debug("Trying to connect again", "address", serverPort)
 
 		//Reattempt
 		client, dialError = rpc.Dial("tcp", serverPort)
@@ -202,17 +202,21 @@ pingLoop:
 	//	fmt.Println("Ping")
 	//	client = HandleCreateClientAndError(serverPort)
 	//}
-	fmt.Println("Client created: ", client)
+	rpcLog.Info("Connected", "address", serverPort)
 	return client
 }
 
 func HandleCallAndError(client *rpc.Client, namedFunctionHandler string,
 	request *Shared.Request, response *Shared.Response,
 	clientNum int, brokerRes *Shared.Response) int {
+	var node = strconv.Itoa(clientNum + 1)
+	var start = time.Now()
 	var namedFunctionHandlerError error = client.Call(namedFunctionHandler, request, response)
+	rpcTimeMetric.With(node, namedFunctionHandler).ObserveSince(start)
 
 	//fmt.Println("error: ", namedFunctionHandlerError)
 	if namedFunctionHandlerError != nil {
+		rpcFailuresMetric.With(node, namedFunctionHandler).Inc()
 
 		//Handle all other threads
 		for i := 0; i < WORKERS; i++ {
@@ -222,14 +226,14 @@ func HandleCallAndError(client *rpc.Client, namedFunctionHandler string,
 			}
 		}
 		client := HandleCreateClientAndError(clientsPorts[clientNum])
+		reconnectsMetric.With(node).Inc()
 
 		Clients[clientNum] = client
 		brokerRes.Resend = true
 		response.Resend = true
 
-		fmt.Println("client being weird? :", client)
-		fmt.Println("Clients as list: ", Clients)
-		fmt.Println("broker response resend value: ", brokerRes.Resend)
+		rpcLog.Warn("A call to a worker failed, so it was connected to again", "worker", clientNum,
+			"method", namedFunctionHandler, "error", namedFunctionHandlerError)
 
 		time.Sleep(500 * time.Millisecond)
 
@@ -248,7 +252,7 @@ func flipWorldCellsIteration(oldWorld, newWorld [][]byte, turn, imageHeight, ima
 		for j := 0; j < imageWidth; j++ {
 			//If the cell has changed since the last iteration, we need to send an event to say so
 			if oldWorld[i][j] != newWorld[i][j] {
-				flippedCells = append(flippedCells, util.Cell{X: i, Y: j})
+				flippedCells = append(flippedCells, util.Cell{X: j, Y: i})
 			}
 		}
 	}
@@ -0,0 +1,21 @@
+package main
+
+import "uk.ac.bris.cs/gameoflife/metrics"
+
+//What the broker reports at /metrics, when the metrics are served
+var (
+	turnsMetric    = metrics.NewCounter("gol_turns_completed_total", "Turns completed.")
+	turnRateMetric = metrics.NewMeter("gol_turns_per_second", "Turns completed per second.")
+	rpcTimeMetric  = metrics.NewSummaryVec("gol_rpc_seconds",
+		"How long calls to each node took, including failed ones.", "node", "method")
+	rpcFailuresMetric = metrics.NewCounterVec("gol_rpc_failures_total", "Calls to each node that failed.",
+		"node", "method")
+	reconnectsMetric = metrics.NewCounterVec("gol_rpc_reconnects_total",
+		"Times the broker has connected to each node again after a failed call.", "node")
+)
+
+func init() {
+	metrics.NewGaugeFunc("gol_population", "Alive cells in the current world.", func() float64 {
+		return float64(getAliveCellsCount(getCurrentWorld()))
+	})
+}
@@ -6,7 +6,8 @@ import (
 )
 
 // WriteFromFileIO is a helper function of distributor. We use this to create an initial world map from a file name
-func WriteFromFileIO(imageHeight, imageWidth int, c DistributorChannels) [][]byte {
+// An empty inputFile reads the image checked against for the world's size.
+func WriteFromFileIO(imageHeight, imageWidth int, inputFile string, c DistributorChannels) [][]byte {
 
 	//We create the worlds
 	var world = make([][]byte, imageHeight)
@@ -15,14 +16,16 @@ func WriteFromFileIO(imageHeight, imageWidth int, c DistributorChannels) [][]byt
 	}
 
 	//We set the command to input to be able to read from the file
-	c.ioFilename <- strconv.Itoa(imageWidth) + "x" + strconv.Itoa(imageHeight) + "x0"
-	c.ioCommand <- ioInput
+	if inputFile != "" {
+		c.ioFilename <- inputFile
+	} else {
+		c.ioFilename <- strconv.Itoa(imageWidth) + "x" + strconv.Itoa(imageHeight) + "x0"
+	}
+	c.ioCommand <- ioInputRows
 
 	for i := 0; i < imageHeight; i++ {
-		for j := 0; j < imageWidth; j++ {
-			//We populate the slice one input at a time.
-			world[i][j] = <-(c.ioInput)
-		}
+		//We populate the slice one row at a time.
+		world[i] = <-(c.ioInputRows)
 	}
 
 	return world
@@ -31,11 +34,12 @@ func WriteFromFileIO(imageHeight, imageWidth int, c DistributorChannels) [][]byt
 //Helper function of distributor. We use this to create a .pgm file from a given world map
 func writeToFileIO(world [][]byte, p Shared.Params, filename string,
 	c DistributorChannels) {
-	c.ioCommand <- ioOutput
+	c.ioCommand <- ioOutputRows
 	c.ioFilename <- filename
 	for i := 0; i < p.ImageHeight; i++ {
-		for j := 0; j < p.ImageWidth; j++ {
-			c.ioOutput <- world[i][j]
-		}
+		//The io goroutine keeps the rows until the file is written, so it gets a copy
+		row := make([]byte, p.ImageWidth)
+		copy(row, world[i])
+		c.ioOutputRows <- row
 	}
 }
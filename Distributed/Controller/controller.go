@@ -4,9 +4,11 @@ import (
 	"flag"
 	"fmt"
 	"runtime"
+	"sync"
 	"time"
 	"uk.ac.bris.cs/gameoflife/Distributed/Shared"
 	"uk.ac.bris.cs/gameoflife/Distributed/SharedSDL"
+	"uk.ac.bris.cs/gameoflife/logging"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
@@ -14,6 +16,29 @@ const LIVE = 255
 
 var Channels DistributorChannels
 
+//The last turn the broker reported, which every log message carries
+type reportedTurnStruct struct {
+	turn int
+	lock sync.Mutex
+}
+
+var reportedTurn reportedTurnStruct
+
+var controllerLog = logging.Component("controller")
+
+func changeReportedTurn(input int) {
+	reportedTurn.lock.Lock()
+	reportedTurn.turn = input
+	reportedTurn.lock.Unlock()
+}
+
+func getReportedTurn() int {
+	reportedTurn.lock.Lock()
+	var temp = reportedTurn.turn
+	reportedTurn.lock.Unlock()
+	return temp
+}
+
 type DistributorChannels struct {
 	events    chan<- Shared.Event
 	ioCommand chan<- IoCommand
@@ -22,6 +47,9 @@ type DistributorChannels struct {
 	ioFilename chan<- string
 	ioOutput   chan<- byte
 	ioInput    <-chan byte
+
+	ioOutputRows chan<- []byte
+	ioInputRows  <-chan []byte
 }
 
 type ControllerOperations struct{}
@@ -53,14 +81,12 @@ func flipWorldCellsInitial(world [][]byte, imageHeight, imageWidth, turn int, c
 
 //Main logic where we control all of our AWS nodes. Also controls the ticker and keypress logic as well.
 func controller(params Shared.Params, channels DistributorChannels, keyPresses <-chan rune) {
-	fmt.Println("Server port: ", params.ServerPort)
+	controllerLog.Info("Connecting to the broker", "address", params.ServerPort)
 	var client = Shared.HandleCreateClientAndError(params.ServerPort)
-	fmt.Println(client)
 	Channels = channels
 
 	//Create request response pair
 	request, response := createRequestResponsePair(params, channels)
-	fmt.Println("Actual address: ", &request.CallAlive)
 
 	//Make a ticker for the updates
 	ticker := time.NewTicker(2 * time.Second)
@@ -69,13 +95,13 @@ func controller(params Shared.Params, channels DistributorChannels, keyPresses <
 	go determineKeyPress(client, keyPresses, &request, response, ticker, channels)
 
 	//We set up our broker
-	fmt.Println("Sending a call")
+	controllerLog.Info("Starting the game on the broker")
 	//channels.events <- Shared.TurnComplete{}
 	Shared.HandleCallAndError(client, Shared.BrokerHandler, &request, response)
 	channels.events <- Shared.FinalTurnComplete{
 		CompletedTurns: params.Turns,
 		Alive:          calculateAliveCells(response.World)}
-	fmt.Println("Shutting down")
+	controllerLog.Info("Game finished, shutting down")
 	//Shut down the game safely
 	defer handleGameShutDown(client, response, params, channels, ticker)
 }
@@ -85,10 +111,6 @@ func main() {
 	var params Shared.Params
 
 	server := flag.String("server", "127.0.0.1:8030", "IP:port string to connect to as server")
-	flag.Parse()
-
-	params.ServerPort = *server
-	fmt.Println("Server: ", *server)
 
 	flag.IntVar(
 		&params.Threads,
@@ -99,14 +121,14 @@ func main() {
 	flag.IntVar(
 		&params.ImageWidth,
 		"w",
-		512,
-		"Specify the width of the image. Defaults to 512.")
+		0,
+		"Specify the width of the image. Defaults to the input file's, or 512 without one.")
 
 	flag.IntVar(
 		&params.ImageHeight,
 		"h",
-		512,
-		"Specify the height of the image. Defaults to 512.")
+		0,
+		"Specify the height of the image. Defaults to the input file's, or 512 without one.")
 
 	flag.IntVar(
 		&params.Turns,
@@ -114,8 +136,61 @@ func main() {
 		10000,
 		"Specify the number of turns to process. Defaults to 10000.")
 
+	flag.StringVar(
+		&params.InputFile,
+		"input",
+		"",
+		"Specify a .pgm, .rle, .cells or Life 1.06 pattern file to load. Defaults to check/images/WxHx0.pgm.")
+
+	offset := flag.String(
+		"offset",
+		"",
+		"Specify x,y for the top left corner of the input in a larger world. Defaults to centring the input.")
+
+	var stamps util.Stamps
+	flag.Var(
+		&stamps,
+		"stamp",
+		"Stamp a pattern on the world, as \"path x,y [rotate=90|180|270] [flip] [or|xor]\". May be given more than once.")
+
+	compose := flag.String(
+		"compose",
+		"",
+		"Specify a file of stamps, one per line, to place before any given with -stamp. Disabled by default.")
+
+	flag.Float64Var(
+		&params.Threshold,
+		"threshold",
+		0.5,
+		"Specify the fraction of a .pgm's maxval at or above which a pixel is alive. Defaults to 0.5.")
+
+	flag.StringVar(
+		&params.OutputFormat,
+		"format",
+		"pgm",
+		"Specify the format of saved images: pgm, rle, cells or life106. Defaults to pgm.")
+
+	logFlags := logging.AddFlags()
+
 	flag.Parse()
 
+	logFlags.Setup("controller", "turn", logging.Lazy(func() interface{} { return getReportedTurn() }))
+	params.ServerPort = *server
+
+	if *offset != "" {
+		var cell util.Cell
+		_, err := fmt.Sscanf(*offset, "%d,%d", &cell.X, &cell.Y)
+		util.Check(err)
+		params.InputOffset = &cell
+	}
+	if *compose != "" {
+		composition, err := util.ReadStampFile(*compose)
+		util.Check(err)
+		stamps = append(composition, stamps...)
+	}
+	params.Stamps = stamps
+	params = resolveParams(params)
+
 	fmt.Println("Threads:", params.Threads)
 	fmt.Println("Width:", params.ImageWidth)
 	fmt.Println("Height:", params.ImageHeight)
@@ -123,6 +198,12 @@ func main() {
 	keyPresses := make(chan rune, 10)
 	events := make(chan Shared.Event, 1000)
 
+	//Ctrl-C or a terminate signal stops the run the same way as q, so the world is saved first
+	util.OnShutdownSignal(func() {
+		controllerLog.Warn("Interrupted, saving the world before quitting")
+		keyPresses <- 'q'
+	})
+
 	/*listener, _ := net.Listen("tcp", ":8035")
 	defer func(listener net.Listener) {
 		err := listener.Close()
@@ -19,6 +19,8 @@ func Run(p Shared.Params, events chan<- Shared.Event, keyPresses <-chan rune) {
 	ioFilename := make(chan string, 1)
 	ioOutput := make(chan uint8)
 	ioInput := make(chan uint8)
+	ioOutputRows := make(chan []byte)
+	ioInputRows := make(chan []byte)
 
 	ioChannels := IoChannels{
 		command:  ioCommand,
@@ -26,6 +28,9 @@ func Run(p Shared.Params, events chan<- Shared.Event, keyPresses <-chan rune) {
 		filename: ioFilename,
 		output:   ioOutput,
 		input:    ioInput,
+
+		outputRows: ioOutputRows,
+		inputRows:  ioInputRows,
 	}
 	go startIo(p, ioChannels)
 
@@ -36,6 +41,9 @@ func Run(p Shared.Params, events chan<- Shared.Event, keyPresses <-chan rune) {
 		ioFilename: ioFilename,
 		ioOutput:   ioOutput,
 		ioInput:    ioInput,
+
+		ioOutputRows: ioOutputRows,
+		ioInputRows:  ioInputRows,
 	}
 	controller(p, distributorChannels, keyPresses)
 }
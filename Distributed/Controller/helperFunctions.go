@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"net/rpc"
 	"os"
 	"strconv"
@@ -17,21 +16,28 @@ func aliveCellsReporter(ticker *time.Ticker, c DistributorChannels,
 	c.events <- Shared.AliveCellsCount{CompletedTurns: 0, CellsCount: 0}
 	flipWorldCellsInitial(request.World, request.Parameters.ImageHeight, request.Parameters.ImageWidth, 0, c)
 
+	//The broker gives the cells flipped since the world sent with the call, so that is the world last shown
+	var info = *request
 	for {
 		select {
 		//When the ticker triggers,
 		//we send an RPC call to return the number of alive cells, and number of turns processed
 		case <-ticker.C:
-			fmt.Println("Sending the stuff")
+			controllerLog.Debug("Asking the broker for the world")
 
-			Shared.HandleCallAndError(client, Shared.BrokerInfo, request, response)
-			c.events <- Shared.AliveCellsCount{
-				CompletedTurns: response.Turns,
-				CellsCount:     response.AliveCells}
-			for i := 0; i < len(response.FlippedCells); i++ {
-				c.events <- Shared.CellFlipped{CompletedTurns: response.Turns, Cell: response.FlippedCells[i]}
+			var infoResponse = new(Shared.Response)
+			Shared.HandleCallAndError(client, Shared.BrokerInfo, &info, infoResponse)
+			changeReportedTurn(infoResponse.Turns)
+			for i := 0; i < len(infoResponse.FlippedCells); i++ {
+				c.events <- Shared.CellFlipped{CompletedTurns: infoResponse.Turns, Cell: infoResponse.FlippedCells[i]}
 			}
-			c.events <- Shared.TurnComplete{CompletedTurns: response.Turns}
+			c.events <- Shared.TurnComplete{CompletedTurns: infoResponse.Turns}
+			//Sent once the window holds the turn, with the cells for it to match if its count differs
+			c.events <- Shared.AliveCellsCount{
+				CompletedTurns: infoResponse.Turns,
+				CellsCount:     infoResponse.AliveCells,
+				Alive:          calculateAliveCells(infoResponse.World)}
+			info.World = infoResponse.World
 			//fmt.Println("On turn: ", response.Turns, ", Alive cells: ", response.AliveCells)
 		}
 	}
@@ -57,7 +63,7 @@ func createRequestResponsePair(p Shared.Params, c DistributorChannels) (Shared.R
 
 	//Forms the request which contains the [][]byte version of the PGM file
 	request := Shared.Request{
-		World:       WriteFromFileIO(p.ImageHeight, p.ImageWidth, c),
+		World:       WriteFromFileIO(p.ImageHeight, p.ImageWidth, p.InputFile, c),
 		Parameters:  p,
 		Events:      c.events,
 		CurrentTurn: make(chan int, 1),
@@ -104,6 +110,8 @@ func handleGameShutDown(client *rpc.Client, response *Shared.Response,
 func determineKeyPress(client *rpc.Client, keyPresses <-chan rune,
 	req *Shared.Request, res *Shared.Response,
 	ticker *time.Ticker, c DistributorChannels) {
+	//The target speed set with + and -, zero for as fast as possible
+	var rate = 0
 	//We make sure this runs forever while the controller is alive
 	for {
 		select {
@@ -120,12 +128,26 @@ func determineKeyPress(client *rpc.Client, keyPresses <-chan rune,
 					strconv.Itoa(res.Turns)
 				writeToFileIO(res.World, req.Parameters, filename, c)
 			} else if key == 'p' {
-				fmt.Println("Continuing")
+				controllerLog.Info("Pause toggled")
 				Shared.HandleCallAndError(client, Shared.BrokerPause, req, res)
+			} else if key == 'n' {
+				//The broker only runs the one turn if it is paused
+				Shared.HandleCallAndError(client, Shared.BrokerStep, req, res)
+			} else if key == '+' || key == '-' {
+				rate = util.ChangeRate(rate, key == '+')
+				req.TurnsPerSecond = rate
+				Shared.HandleCallAndError(client, Shared.BrokerRate, req, res)
+				c.events <- Shared.RateChanged{CompletedTurns: res.Turns, TurnsPerSecond: rate}
 			} else if key == 'q' {
+				//The broker pauses until another controller connects, and we keep a copy of where it got to
 				Shared.HandleCallAndError(client, Shared.BrokerBackground, req, res)
 				Shared.HandleCallAndError(client, Shared.BrokerInfo, req, res)
+				var filename = strconv.Itoa(req.Parameters.ImageWidth) + "x" +
+					strconv.Itoa(req.Parameters.ImageHeight) + "x" +
+					strconv.Itoa(res.Turns)
+				writeToFileIO(res.World, req.Parameters, filename, c)
 				shutDownIOTickerClient(c, ticker, client)
+				c.events <- Shared.StateChange{CompletedTurns: res.Turns, NewState: Shared.Quitting}
 				os.Exit(0)
 			}
 		}
@@ -1,15 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"strconv"
-	"strings"
 	"uk.ac.bris.cs/gameoflife/Distributed/Shared"
+	"uk.ac.bris.cs/gameoflife/logging"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
+var ioLog = logging.Component("io")
+
 type IoChannels struct {
 	command <-chan IoCommand
 	idle    chan<- bool
@@ -17,6 +19,9 @@ type IoChannels struct {
 	filename <-chan string
 	output   <-chan uint8
 	input    chan<- uint8
+
+	outputRows <-chan []byte
+	inputRows  chan<- []byte
 }
 
 // IoState is the internal ioState of the io goroutine.
@@ -33,38 +38,48 @@ type IoCommand uint8
 //		ioOutput 	= 0
 //		ioInput 	= 1
 //		ioCheckIdle = 2
+//		ioTicker 	= 3
+//		ioOutputRows = 4
+//		ioInputRows = 5
+//
+// ioOutput and ioInput move the world a byte at a time and are only kept for compatibility.
+// ioOutputRows and ioInputRows move a whole row per channel operation, which is much faster for large worlds.
 const (
 	ioOutput IoCommand = iota
 	ioInput
 	ioCheckIdle
 	ioTicker
+	ioOutputRows
+	ioInputRows
 )
 
-// writePgmImage receives an array of bytes and writes it to a pgm file.
-func (io *IoState) writePgmImage() {
+// defaultSize is the width and height of the world when neither the flags nor an input file give one.
+const defaultSize = 512
+
+// conwayRule is the only rule the nodes run, so it is the rule saved alongside patterns.
+const conwayRule = "B3/S23"
+
+// writeImage receives the world with receive and writes it to a file in the output format.
+func (io *IoState) writeImage(receive func() [][]byte) {
 	_ = os.Mkdir("out", os.ModePerm)
 
 	// Request a filename from the distributor.
 	filename := <-io.channels.filename
 
-	file, ioError := os.Create("../../out/" + filename + ".pgm")
-	util.Check(ioError)
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-			panic(err)
-		}
-	}(file)
+	world := receive()
 
-	_, _ = file.WriteString("P5\n")
-	//_, _ = file.WriteString("# PGM file writer by modules (https://github.com/owainkenwayucl/pnmmodules).\n")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageWidth))
-	_, _ = file.WriteString(" ")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageHeight))
-	_, _ = file.WriteString("\n")
-	_, _ = file.WriteString(strconv.Itoa(255))
-	_, _ = file.WriteString("\n")
+	switch io.params.OutputFormat {
+	case "", util.FormatPGM:
+		io.writePgmImage(filename, world)
+	default:
+		io.writePatternImage(filename, world)
+	}
+
+	ioLog.Info("File output done", "file", filename)
+}
 
+// receiveBytes receives the world from the distributor one byte at a time.
+func (io *IoState) receiveBytes() [][]byte {
 	world := make([][]byte, io.params.ImageHeight)
 	for i := range world {
 		world[i] = make([]byte, io.params.ImageWidth)
@@ -72,68 +87,227 @@ func (io *IoState) writePgmImage() {
 
 	for y := 0; y < io.params.ImageHeight; y++ {
 		for x := 0; x < io.params.ImageWidth; x++ {
-
-			val := <-io.channels.output
-			//if val != 0 {
-			//	fmt.Println(x, y)
-			//}
-			world[y][x] = val
+			world[y][x] = <-io.channels.output
 		}
 	}
+	return world
+}
 
-	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
-			_, ioError = file.Write([]byte{world[y][x]})
-			util.Check(ioError)
+// receiveRows receives the world from the distributor one row at a time.
+func (io *IoState) receiveRows() [][]byte {
+	world := make([][]byte, io.params.ImageHeight)
+	for y := range world {
+		world[y] = <-io.channels.outputRows
+	}
+	return world
+}
+
+// writePgmImage writes the world to a pgm file.
+func (io *IoState) writePgmImage(filename string, world [][]byte) {
+	file, ioError := os.Create("../../out/" + filename + ".pgm")
+	util.Check(ioError)
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+			panic(err)
 		}
+	}(file)
+
+	writer := bufio.NewWriter(file)
+	_, _ = writer.WriteString("P5\n")
+	//_, _ = writer.WriteString("# PGM file writer by modules (https://github.com/owainkenwayucl/pnmmodules).\n")
+	_, _ = writer.WriteString(strconv.Itoa(io.params.ImageWidth))
+	_, _ = writer.WriteString(" ")
+	_, _ = writer.WriteString(strconv.Itoa(io.params.ImageHeight))
+	_, _ = writer.WriteString("\n")
+	_, _ = writer.WriteString(strconv.Itoa(255))
+	_, _ = writer.WriteString("\n")
+
+	for y := 0; y < io.params.ImageHeight; y++ {
+		_, ioError = writer.Write(world[y])
+		util.Check(ioError)
 	}
 
+	ioError = writer.Flush()
+	util.Check(ioError)
+
 	ioError = file.Sync()
 	util.Check(ioError)
+}
+
+// writePatternImage writes the world to a pattern file in the output format.
+func (io *IoState) writePatternImage(filename string, world [][]byte) {
+	file, ioError := os.Create("../../out/" + filename + util.Extension(io.params.OutputFormat))
+	util.Check(ioError)
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+			panic(err)
+		}
+	}(file)
+
+	writer := bufio.NewWriter(file)
+	ioError = util.WritePattern(writer, util.PatternFromWorld(world, conwayRule), io.params.OutputFormat)
+	util.Check(ioError)
 
-	fmt.Println("File", filename, "output done!")
+	ioError = writer.Flush()
+	util.Check(ioError)
+
+	ioError = file.Sync()
+	util.Check(ioError)
 }
 
-// readPgmImage opens a pgm file and sends its data as an array of bytes.
-func (io *IoState) readPgmImage() {
+// readImage opens a pattern file, stamps any other patterns on it and sends the world to the distributor with send.
+func (io *IoState) readImage(send func(world [][]byte)) {
 
 	// Request a filename from the distributor.
 	filename := <-io.channels.filename
-	fmt.Println(filename)
+	ioLog.Debug("Reading the input", "file", filename)
+
+	var world [][]byte
+	if io.params.InputFile == "" && len(io.params.Stamps) > 0 {
+		//Stamps without an input file start from an empty world
+		world = make([][]byte, io.params.ImageHeight)
+		for i := range world {
+			world[i] = make([]byte, io.params.ImageWidth)
+		}
+	} else {
+		path, ioError := inputPath(filename)
+		util.Check(ioError)
+		format, ioError := util.DetectFormat(path)
+		util.Check(ioError)
+		if format == util.FormatPGM {
+			world = io.readPgmImage(path)
+		} else {
+			world = io.readPatternImage(path)
+		}
+	}
+
+	//Stamps go on top of the loaded world in the order they were given
+	for _, stamp := range io.params.Stamps {
+		util.Check(stamp.Apply(world))
+	}
+	send(world)
+
+	ioLog.Info("File input done", "file", filename)
+}
+
+// sendBytes sends the world to the distributor one byte at a time.
+func (io *IoState) sendBytes(world [][]byte) {
+	for _, row := range world {
+		for _, b := range row {
+			io.channels.input <- b
+		}
+	}
+}
+
+// sendRows sends the world to the distributor one row at a time.
+// The rows are handed over rather than copied, so the io goroutine must not touch them afterwards.
+func (io *IoState) sendRows(world [][]byte) {
+	for _, row := range world {
+		io.channels.inputRows <- row
+	}
+}
+
+//Gives the path of the file to read for an input filename. A bare WxHx0 name refers to one of the images we check against,
+//any other name must be a file that exists.
+func inputPath(filename string) (string, error) {
+	_, statError := os.Stat(filename)
+	if statError == nil {
+		return filename, nil
+	}
+	var width, height, turn int
+	if _, scanError := fmt.Sscanf(filename, "%dx%dx%d", &width, &height, &turn); scanError == nil &&
+		fmt.Sprintf("%vx%vx%v", width, height, turn) == filename {
+		return "../../check/images/" + filename + ".pgm", nil
+	}
+	return "", statError
+}
 
-	data, ioError := ioutil.ReadFile("../../check/images/" + filename + ".pgm")
+// resolveParams sizes the world from the input file where the width or height is zero.
+// It has to happen before the SDL window is made and the nodes are sent the parameters.
+func resolveParams(params Shared.Params) Shared.Params {
+	if params.InputFile == "" {
+		//Without an input file the size picks one of the images we check against
+		if params.ImageWidth == 0 {
+			params.ImageWidth = defaultSize
+		}
+		if params.ImageHeight == 0 {
+			params.ImageHeight = defaultSize
+		}
+		return params
+	}
+
+	path, ioError := inputPath(params.InputFile)
+	util.Check(ioError)
+	width, height, ioError := util.FileDimensions(path)
+	util.Check(ioError)
+	if params.ImageWidth == 0 {
+		params.ImageWidth = width
+	}
+	if params.ImageHeight == 0 {
+		params.ImageHeight = height
+	}
+	if width > params.ImageWidth || height > params.ImageHeight {
+		panic(fmt.Sprintf("%v is %vx%v, which does not fit in a %vx%v world", params.InputFile, width, height,
+			params.ImageWidth, params.ImageHeight))
+	}
+	return params
+}
+
+// readPgmImage opens a pgm or pbm file and returns the world it holds, placed in a larger world if need be.
+func (io *IoState) readPgmImage(path string) [][]byte {
+	file, ioError := os.Open(path)
 	util.Check(ioError)
-	fmt.Println("File read")
-	fields := strings.Fields(string(data))
+	defer file.Close()
 
-	if fields[0] != "P5" {
-		panic("Not a pgm file")
+	threshold := io.params.Threshold
+	if threshold == 0 {
+		threshold = util.DefaultThreshold
 	}
+	header, image, ioError := util.ReadPNM(file, threshold)
+	util.Check(ioError)
+	ioLog.Debug("File read", "path", path)
 
-	width, _ := strconv.Atoi(fields[1])
-	if width != io.params.ImageWidth {
+	if header.Width > io.params.ImageWidth {
 		panic("Incorrect width")
 	}
 
-	height, _ := strconv.Atoi(fields[2])
-	if height != io.params.ImageHeight {
+	if header.Height > io.params.ImageHeight {
 		panic("Incorrect height")
 	}
 
-	maxval, _ := strconv.Atoi(fields[3])
-	if maxval != 255 {
-		panic("Incorrect maxval/bit depth")
+	if header.Width == io.params.ImageWidth && header.Height == io.params.ImageHeight {
+		return image
 	}
+	return io.placeInWorld(util.PatternFromWorld(image, ""))
+}
+
+// readPatternImage places a pattern in an empty world and returns the world.
+func (io *IoState) readPatternImage(path string) [][]byte {
+	pattern, ioError := util.ReadPatternFile(path)
+	util.Check(ioError)
+	return io.placeInWorld(pattern)
+}
 
-	image := []byte(fields[4])
+// placeInWorld places a pattern in an empty world at the input offset and returns the world.
+func (io *IoState) placeInWorld(pattern util.Pattern) [][]byte {
+	if pattern.Width > io.params.ImageWidth || pattern.Height > io.params.ImageHeight {
+		panic(fmt.Sprintf("Pattern of %vx%v does not fit in the world", pattern.Width, pattern.Height))
+	}
 
-	for _, b := range image {
-		//fmt.Println("Put in")
-		io.channels.input <- b
-		//fmt.Println("Taken out")
+	offset := pattern.Centre(io.params.ImageWidth, io.params.ImageHeight)
+	if io.params.InputOffset != nil {
+		offset = *io.params.InputOffset
+	}
+
+	world := make([][]byte, io.params.ImageHeight)
+	for i := range world {
+		world[i] = make([]byte, io.params.ImageWidth)
 	}
+	pattern.Place(world, offset)
 
-	fmt.Println("File", filename, "input done!")
+	return world
 }
 
 // startIo should be the entrypoint of the io goroutine.
@@ -149,10 +323,15 @@ func startIo(p Shared.Params, c IoChannels) {
 		case command := <-io.channels.command:
 			switch command {
 			case ioInput:
-				fmt.Println("Input triggered")
-				io.readPgmImage()
+				ioLog.Debug("Input triggered")
+				io.readImage(io.sendBytes)
+			case ioInputRows:
+				ioLog.Debug("Input triggered")
+				io.readImage(io.sendRows)
 			case ioOutput:
-				io.writePgmImage()
+				io.writeImage(io.receiveBytes)
+			case ioOutputRows:
+				io.writeImage(io.receiveRows)
 			case ioCheckIdle:
 				io.channels.idle <- true
 			case ioTicker:
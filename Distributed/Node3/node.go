@@ -2,14 +2,15 @@ package main
 
 import (
 	"flag"
-	"fmt"
 	"math/rand"
 	"net"
-	"net/rpc"
 	"os"
 	"sync"
 	"time"
 	"uk.ac.bris.cs/gameoflife/Distributed/Shared"
+	"uk.ac.bris.cs/gameoflife/logging"
+	"uk.ac.bris.cs/gameoflife/metrics"
+	"uk.ac.bris.cs/gameoflife/util"
 )
 
 type currentWorldStruct struct {
@@ -31,8 +32,20 @@ type pausedStruct struct {
 var currentWorld currentWorldStruct
 var currentTurn currentTurnStruct
 var paused pausedStruct
+
+//pauseChange is held while the node is paused or unpaused, so that two changes can't interleave
+var pauseChange sync.Mutex
 var condition sync.WaitGroup
 
+//server keeps count of the calls being handled, so that shutting down can wait for their replies to be sent
+var server Shared.Server
+
+//What the node reports at /metrics, when the metrics are served
+var stripsMetric = metrics.NewCounter("gol_strips_total", "Strips of the world worked on.")
+var stripTimeMetric = metrics.NewCounter("gol_strip_compute_seconds_total", "Time spent computing strips.")
+
+var nodeLog = logging.Component("node")
+
 //General helper function for the global variables
 //Locks current world's lock, changes the world value to the input, then Unlocks it
 func changeCurrentWorld(input [][]byte) {
@@ -43,29 +56,37 @@ func changeCurrentWorld(input [][]byte) {
 
 //General helper function for the global variables
 //Locks current turn's lock, changes the turn value to the input, then Unlocks it
-/*func changeCurrentTurn(input int) {
+func changeCurrentTurn(input int) {
 	currentTurn.lock.Lock()
 	currentTurn.turn = input
 	currentTurn.lock.Unlock()
-}*/
+}
+
+func getCurrentTurn() int {
+	currentTurn.lock.Lock()
+	var temp = currentTurn.turn
+	currentTurn.lock.Unlock()
+	return temp
+}
 
 // GoLWorker does the actual working stuff
 func GoLWorker(inputWorld [][]byte, p Shared.Params) [][]byte {
 	var newWorld [][]byte
 	if p.Turns == 0 {
-		if p.ImageHeight == 16 {
-			fmt.Println("Auto done: ", inputWorld)
-		}
+		nodeLog.Debug("No turns to run, sending the strip back")
 		return inputWorld
 	}
 	//fmt.Println("Height : ", p.ImageHeight, " Width : ", p.ImageWidth)
+	var start = time.Now()
 	newWorld = worker(p.ImageHeight, p.ImageWidth, inputWorld)
+	stripTimeMetric.Add(time.Since(start).Seconds())
+	stripsMetric.Inc()
 	//currentWorld <- newWorld
 	inputWorld = newWorld
 	//turn <- i + 1
 	changeCurrentWorld(inputWorld)
 
-	fmt.Println("Pausing")
+	nodeLog.Debug("Strip done, waiting here while paused")
 	paused.lock.Lock()
 	paused.lock.Unlock()
 	//Once all turns have been processed, free the condition variable
@@ -118,20 +139,18 @@ func (s *GoLOperations) GoLManager(req *Shared.Request, res *Shared.Response) (e
 		res.World = currentWorld.world
 		currentWorld.lock.Unlock()
 	} else { */ //If the node is fresh and no previous GoL instance was running in the past
-	fmt.Println("Called")
+	changeCurrentTurn(req.Turn)
+	nodeLog.Debug("Strip received", "rows", len(req.World))
 	condition.Add(1)
 	res.World = GoLWorker(req.World, req.Parameters)
-	if req.Parameters.ImageWidth == 16 && req.Parameters.Turns == 1 {
-		fmt.Println(res.World)
-	}
-	fmt.Println("Done")
+	nodeLog.Debug("Strip sent back")
 	return
 }
 
 // KYS :Handler whenever the user presses "K".
 //Called from the local controller to tell the AWS node to kill itself
 func (s *GoLOperations) KYS(*Shared.Request, *Shared.Response) (err error) {
-	fmt.Println("Terminated successfully")
+	nodeLog.Info("Killed by the broker")
 
 	defer os.Exit(0)
 	return
@@ -139,44 +158,65 @@ func (s *GoLOperations) KYS(*Shared.Request, *Shared.Response) (err error) {
 }
 
 // PauseManager :Handler whenever the user presses "p"
-//If already paused then unpause, otherwise pause.
+//Pauses or unpauses the node as asked. Asking for the state it is already in does nothing.
 func (s *GoLOperations) PauseManager(req *Shared.Request, res *Shared.Response) (err error) {
-	if !req.Paused && paused.pause {
-		fmt.Println("Unlocking")
-		paused.lock.Unlock()
-	} else if req.Paused {
-		fmt.Println("Locking")
+	setPaused(req.Paused)
+	return
+}
+
+//General helper function for the global variables
+//Holds the pause lock while paused, so that GoLWorker waits on it after each strip
+func setPaused(pause bool) {
+	pauseChange.Lock()
+	defer pauseChange.Unlock()
+	if pause == paused.pause {
+		return
+	}
+	if pause {
+		nodeLog.Debug("Pausing")
 		paused.lock.Lock()
+	} else {
+		nodeLog.Debug("Resuming")
+		paused.lock.Unlock()
 	}
-	paused.pause = req.Paused
-	fmt.Println(paused.pause)
-	return
+	paused.pause = pause
 }
 
 // BackgroundManager :Handler whenever the user presses "q"
 //	WHen the local controller is killed, then pause the node and then wait until a new local controller is created
 // This is a form of fault tolerance.
 func (s *GoLOperations) BackgroundManager(*Shared.Request, *Shared.Response) (err error) {
-	fmt.Println("Stopped - waiting for a controller to reconnect to it.")
-	paused.pause = true
-	paused.lock.Lock()
+	nodeLog.Info("Stopped, waiting for a controller to reconnect")
+	setPaused(true)
 	return
 }
 
 func main() {
-	fmt.Println("hi")
 	pAddr := flag.String("port", "8033", "Port to listen on")
-	fmt.Println(*pAddr)
+	metricsAddr := flag.String("metrics", "", "Address to serve metrics on at /metrics, e.g. :9131. Off by default")
+	logFlags := logging.AddFlags()
 	flag.Parse()
+	logFlags.Setup("node3", "turn", logging.Lazy(func() interface{} { return getCurrentTurn() }))
+	if *metricsAddr != "" {
+		nodeLog.Info("Serving metrics", "url", "http://"+metrics.Serve(*metricsAddr)+"/metrics")
+	}
 	rand.Seed(time.Now().UnixNano())
 	Shared.HandleRegisterAndError(&GoLOperations{})
 	listener, _ := net.Listen("tcp", ":"+*pAddr)
-	fmt.Println("Waiting")
+	nodeLog.Info("Waiting for the broker", "port", *pAddr)
 	defer func(listener net.Listener) {
 		err := listener.Close()
 		if err != nil {
 			panic(err)
 		}
 	}(listener)
-	rpc.Accept(listener)
+	//Ctrl-C or a terminate signal lets the current strip finish and be sent back before exiting
+	util.OnShutdownSignal(func() {
+		nodeLog.Info("Finishing the current strip before exiting")
+		//A strip held back by a pause is sent back rather than waited on forever
+		setPaused(false)
+		server.Stop()
+		os.Exit(0)
+	})
+	server.Accept(listener)
 }
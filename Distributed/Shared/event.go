@@ -16,9 +16,11 @@ type Event interface {
 
 // AliveCellsCount is an Event notifying the user about the number of currently alive cells.
 // This Event should be sent every 2s.
+// Alive holds the cells themselves, so that a GUI whose count differs can show the world exactly again.
 type AliveCellsCount struct { // implements Event
 	CompletedTurns int
 	CellsCount     int
+	Alive          []util.Cell
 }
 
 // ImageOutputComplete is an Event notifying the user about the completion of output.
@@ -52,6 +54,13 @@ type CellFlipped struct { // implements Event
 	Cell           util.Cell
 }
 
+// RateChanged is an Event notifying the user about the speed the game has been set to with + or -.
+// TurnsPerSecond is zero when the game runs as fast as it can.
+type RateChanged struct { // implements Event
+	CompletedTurns int
+	TurnsPerSecond int
+}
+
 // TurnComplete is an Event notifying the GUI about turn completion.
 // SDL will render a frame when this event is sent.
 // All CellFlipped events must be sent *before* TurnComplete.
@@ -98,6 +107,17 @@ func (event AliveCellsCount) GetCompletedTurns() int {
 	return event.CompletedTurns
 }
 
+func (event RateChanged) String() string {
+	if event.TurnsPerSecond == 0 {
+		return fmt.Sprintf("Running at full speed")
+	}
+	return fmt.Sprintf("Running at %v turns per second", event.TurnsPerSecond)
+}
+
+func (event RateChanged) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
 func (event ImageOutputComplete) String() string {
 	return fmt.Sprintf("File %v output complete", event.Filename)
 }
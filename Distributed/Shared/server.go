@@ -0,0 +1,96 @@
+package Shared
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io"
+	"net"
+	"net/rpc"
+	"sync"
+)
+
+// Server serves the registered handlers like rpc.Accept, keeping count of the calls that haven't been replied to
+// so that shutting down can wait for their replies to be sent.
+type Server struct {
+	lock     sync.Mutex
+	stopping bool
+	inFlight sync.WaitGroup
+}
+
+// Accept serves every connection made to listener until it is closed.
+func (s *Server) Accept(listener net.Listener) {
+	for {
+		conn, acceptError := listener.Accept()
+		if acceptError != nil {
+			return
+		}
+		go rpc.ServeCodec(s.newCodec(conn))
+	}
+}
+
+// Stop turns away any new calls and waits until every call already being handled has had its reply sent.
+func (s *Server) Stop() {
+	s.lock.Lock()
+	s.stopping = true
+	s.lock.Unlock()
+	s.inFlight.Wait()
+}
+
+//Helper function of Server
+//Counts a call in, unless the server is stopping
+func (s *Server) start() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stopping {
+		return false
+	}
+	s.inFlight.Add(1)
+	return true
+}
+
+//The gob codec rpc.Accept uses, counting each call from reading its request to writing its reply
+type countingCodec struct {
+	server  *Server
+	conn    io.ReadWriteCloser
+	decoder *gob.Decoder
+	encoder *gob.Encoder
+	buffer  *bufio.Writer
+}
+
+func (s *Server) newCodec(conn io.ReadWriteCloser) *countingCodec {
+	buffer := bufio.NewWriter(conn)
+	return &countingCodec{server: s, conn: conn, decoder: gob.NewDecoder(conn), encoder: gob.NewEncoder(buffer),
+		buffer: buffer}
+}
+
+func (c *countingCodec) ReadRequestHeader(request *rpc.Request) error {
+	if err := c.decoder.Decode(request); err != nil {
+		return err
+	}
+	//Closing the connection is all a caller can be told once the server is stopping
+	if !c.server.start() {
+		return io.EOF
+	}
+	return nil
+}
+
+func (c *countingCodec) ReadRequestBody(body interface{}) error {
+	return c.decoder.Decode(body)
+}
+
+func (c *countingCodec) WriteResponse(response *rpc.Response, body interface{}) error {
+	defer c.server.inFlight.Done()
+	if err := c.encoder.Encode(response); err != nil {
+		c.Close()
+		return err
+	}
+	if err := c.encoder.Encode(body); err != nil {
+		c.Close()
+		return err
+	}
+	return c.buffer.Flush()
+}
+
+func (c *countingCodec) Close() error {
+	return c.conn.Close()
+}
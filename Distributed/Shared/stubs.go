@@ -12,6 +12,17 @@ type Params struct {
 	ImageWidth  int
 	ImageHeight int
 	ServerPort  string
+	// InputFile is the .pgm, .rle, .cells or Life 1.06 file to load. Empty means check/images/WxHx0.pgm.
+	InputFile string
+	// InputOffset places the top left corner of the input in a larger world. Nil centres the input in the world.
+	InputOffset *util.Cell
+	// Stamps are patterns placed on the world once it has been loaded, in order.
+	// With no InputFile they are placed on an empty world rather than the image for the world's size.
+	Stamps []util.Stamp
+	// OutputFormat is the format of saved images, one of the util.Format constants. Empty means "pgm".
+	OutputFormat string
+	// Threshold is the fraction of a pgm's maxval at or above which a pixel is alive. Zero means util.DefaultThreshold.
+	Threshold float64
 }
 
 var GoLHandler = "GoLOperations.GoLManager"
@@ -24,6 +35,8 @@ var BrokerInfo = "BrokerOperations.BrokerInfo"
 var BrokerKill = "BrokerOperations.KYS"
 var BrokerPause = "BrokerOperations.PauseManager"
 var BrokerBackground = "BrokerOperations.BackgroundManager"
+var BrokerRate = "BrokerOperations.RateManager"
+var BrokerStep = "BrokerOperations.StepManager"
 
 var ControllerHandler = "ControllerOperations.CellReporter"
 
@@ -47,6 +60,8 @@ type Request struct {
 	OldWorld     [][]byte
 	Turn         int
 	Paused       bool
+	// TurnsPerSecond is the target speed sent with BrokerRate. Zero means as fast as possible.
+	TurnsPerSecond int
 }
 
 func HandleError(err error) {
@@ -0,0 +1,84 @@
+package SharedSDL
+
+import (
+	"math"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// neverAlive is the age of a dead cell that hasn't been alive since the window opened, so it never leaves a trail.
+const neverAlive = math.MaxUint16
+
+// cellState is the window's own model of the world. It knows which cells are alive and, for each cell,
+// how many turns it has been alive for, or dead for if it isn't alive, so that cells can be coloured by age.
+type cellState struct {
+	width, height int
+	alive         []bool
+	age           []uint16
+	count         int
+	// settled is set when a turn completes and cleared by any change after it, so that the count is only
+	// compared with the engine's while the model holds exactly the turn it was last told about.
+	settled bool
+}
+
+func newCellState(width, height int) cellState {
+	state := cellState{width: width, height: height, alive: make([]bool, width*height), age: make([]uint16, width*height)}
+	for i := range state.age {
+		state.age[i] = neverAlive
+	}
+	return state
+}
+
+//Sets the cell at x, y alive or dead, starting its age again if that changes it
+func (s *cellState) set(x, y int, alive bool) {
+	s.settled = false
+	i := y*s.width + x
+	if s.alive[i] == alive {
+		return
+	}
+	s.alive[i] = alive
+	s.age[i] = 0
+	if alive {
+		s.count++
+	} else {
+		s.count--
+	}
+}
+
+//Ages every cell by a turn, stopping at the oldest age that can be held
+func (s *cellState) turnComplete() {
+	s.settled = true
+	for i, age := range s.age {
+		if age < neverAlive-1 {
+			s.age[i] = age + 1
+		}
+	}
+}
+
+//Kills every cell without leaving trails
+func (s *cellState) clear() {
+	for i := range s.alive {
+		s.alive[i] = false
+		s.age[i] = neverAlive
+	}
+	s.count = 0
+	s.settled = false
+}
+
+//Makes exactly the given cells alive, returning how many cells that changed
+func (s *cellState) reset(alive []util.Cell) int {
+	var wanted = make([]bool, len(s.alive))
+	for _, cell := range alive {
+		if cell.X >= 0 && cell.Y >= 0 && cell.X < s.width && cell.Y < s.height {
+			wanted[cell.Y*s.width+cell.X] = true
+		}
+	}
+	var changed = 0
+	for i, alive := range wanted {
+		if s.alive[i] != alive {
+			s.set(i%s.width, i/s.width, alive)
+			changed++
+		}
+	}
+	return changed
+}
@@ -0,0 +1,175 @@
+package SharedSDL
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestCellState checks how the window's model of a 2x2 world ages cells, leaves trails as they die, counts them,
+// and is cleared and reset.
+func TestCellState(t *testing.T) {
+	type cell struct {
+		alive bool
+		age   uint16
+	}
+	never := cell{false, neverAlive}
+	tests := []struct {
+		name    string
+		change  func(s *cellState) int
+		changed int
+		cells   [4]cell
+		count   int
+		settled bool
+	}{
+		{"new", func(s *cellState) int { return 0 }, 0, [4]cell{never, never, never, never}, 0, false},
+		{"born", func(s *cellState) int {
+			s.set(1, 0, true)
+			return 0
+		}, 0, [4]cell{never, {true, 0}, never, never}, 1, false},
+		{"ageing", func(s *cellState) int {
+			s.set(1, 0, true)
+			s.turnComplete()
+			s.turnComplete()
+			s.turnComplete()
+			return 0
+		}, 0, [4]cell{never, {true, 3}, never, never}, 1, true},
+		{"set-alive-again", func(s *cellState) int {
+			s.set(1, 0, true)
+			s.turnComplete()
+			s.set(1, 0, true)
+			return 0
+		}, 0, [4]cell{never, {true, 1}, never, never}, 1, false},
+		{"trail", func(s *cellState) int {
+			s.set(0, 1, true)
+			s.turnComplete()
+			s.turnComplete()
+			s.set(0, 1, false)
+			s.turnComplete()
+			return 0
+		}, 0, [4]cell{never, never, {false, 1}, never}, 0, true},
+		{"oldest", func(s *cellState) int {
+			s.set(1, 1, true)
+			s.age[3] = neverAlive - 2
+			s.turnComplete()
+			s.turnComplete()
+			return 0
+		}, 0, [4]cell{never, never, never, {true, neverAlive - 1}}, 1, true},
+		{"clear", func(s *cellState) int {
+			s.set(0, 0, true)
+			s.set(1, 1, true)
+			s.turnComplete()
+			s.clear()
+			return 0
+		}, 0, [4]cell{never, never, never, never}, 0, false},
+		{"reset", func(s *cellState) int {
+			s.set(0, 0, true)
+			s.set(1, 0, true)
+			s.turnComplete()
+			return s.reset([]util.Cell{{X: 1, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 0}, {X: -1, Y: 0}})
+		}, 2, [4]cell{{false, 0}, {true, 1}, never, {true, 0}}, 2, false},
+		{"reset-unchanged", func(s *cellState) int {
+			s.set(0, 1, true)
+			s.turnComplete()
+			return s.reset([]util.Cell{{X: 0, Y: 1}})
+		}, 0, [4]cell{never, never, {true, 1}, never}, 1, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newCellState(2, 2)
+			if changed := test.change(&s); changed != test.changed {
+				t.Errorf("Expected %v cells changed, got %v", test.changed, changed)
+			}
+			for i, expected := range test.cells {
+				if got := (cell{s.alive[i], s.age[i]}); got != expected {
+					t.Errorf("Expected cell %v to be %+v, got %+v", i, expected, got)
+				}
+			}
+			if s.count != test.count {
+				t.Errorf("Expected a count of %v, got %v", test.count, s.count)
+			}
+			if s.settled != test.settled {
+				t.Errorf("Expected settled to be %v, got %v", test.settled, s.settled)
+			}
+		})
+	}
+}
+
+// TestPick checks which cell stands for a block when zoomed out: the youngest alive one, or the most recently dead.
+func TestPick(t *testing.T) {
+	//A 4x4 world, with the ages of alive cells in upper case and dead cells in lower case, . for never alive
+	s := newCellState(4, 4)
+	rows := []string{
+		"A.cb",
+		"Ca.d",
+		"..ab",
+		"...B",
+	}
+	for y, row := range rows {
+		for x, c := range row {
+			switch {
+			case c >= 'A' && c <= 'Z':
+				s.set(x, y, true)
+				s.age[y*4+x] = uint16(c - 'A')
+			case c >= 'a' && c <= 'z':
+				s.age[y*4+x] = uint16(c - 'a')
+			}
+		}
+	}
+	v := &viewport{worldWidth: 4, worldHeight: 4}
+	tests := []struct {
+		name       string
+		x, y, size int
+		picked     int
+	}{
+		{"single-cell", 1, 0, 1, 1},
+		{"youngest-alive", 0, 0, 2, 0},
+		{"alive-over-younger-dead", 0, 0, 4, 0},
+		{"most-recently-dead", 2, 0, 2, 3},
+		{"never-alive", 0, 2, 2, 8},
+		{"alive-over-dead", 2, 2, 2, 15},
+		{"clipped-at-edge", 3, 2, 4, 15},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if picked := v.pick(&s, test.x, test.y, test.size); picked != test.picked {
+				t.Errorf("Expected cell %v, got %v", test.picked, picked)
+			}
+		})
+	}
+}
+
+// TestPaletteColour checks the colours of alive cells by age and of dead cells with and without trails.
+func TestPaletteColour(t *testing.T) {
+	mono, heat := palettes[0], palettes[1]
+	tests := []struct {
+		name    string
+		palette *palette
+		alive   bool
+		age     uint16
+		trails  bool
+		colour  [3]byte
+	}{
+		{"mono-newborn", mono, true, 0, false, [3]byte{0xFF, 0xFF, 0xFF}},
+		{"mono-old", mono, true, 1000, false, [3]byte{0xFF, 0xFF, 0xFF}},
+		{"heat-newborn", heat, true, 0, false, heat.stops[0]},
+		{"heat-old", heat, true, oldAge, false, heat.stops[len(heat.stops)-1]},
+		{"heat-older", heat, true, oldAge + 100, false, heat.stops[len(heat.stops)-1]},
+		{"dead-without-trails", heat, false, 0, false, [3]byte{}},
+		{"just-died", heat, false, 0, true, heat.trail},
+		{"fading", mono, false, trailLength / 2, true, [3]byte{0x38, 0x38, 0x38}},
+		{"faded", heat, false, trailLength, true, [3]byte{}},
+		{"never-alive", heat, false, neverAlive, true, [3]byte{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if colour := test.palette.colour(test.alive, test.age, test.trails); colour != test.colour {
+				t.Errorf("Expected %v, got %v", test.colour, colour)
+			}
+		})
+	}
+	//Alive cells get further along the palette as they age
+	if heat.colour(true, 4, false) == heat.colour(true, 64, false) {
+		t.Errorf("Expected cells 4 and 64 turns old to be coloured differently")
+	}
+}
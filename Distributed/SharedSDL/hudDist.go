@@ -0,0 +1,95 @@
+package SharedSDL
+
+import (
+	"fmt"
+	"time"
+)
+
+// hudPeriod is how often the turns per second and frames per second shown on the HUD are worked out.
+const hudPeriod = 500 * time.Millisecond
+
+// The colours of the HUD's text and of the box behind it.
+var (
+	hudTextColour = [3]byte{0xFF, 0xD0, 0x40}
+	hudBoxColour  = [3]byte{0x10, 0x10, 0x10}
+)
+
+// hud is the overlay in the top left corner of the window showing how the game is doing.
+type hud struct {
+	visible bool
+	threads int
+	paused  bool
+	turn    int
+	// palette is the name of the palette cells are coloured with, and trails whether dead cells leave trails.
+	palette string
+	trails  bool
+	// The rates shown, and the turn, frame count and time they are next worked out from.
+	turnsPerSecond  float64
+	framesPerSecond float64
+	periodTurn      int
+	periodFrames    int
+	periodStart     time.Time
+}
+
+//Counts a frame drawn at now, working out the rates again once hudPeriod has passed
+func (h *hud) frameDrawn(now time.Time) {
+	h.periodFrames++
+	if h.periodStart.IsZero() {
+		h.periodStart = now
+		h.periodTurn = h.turn
+		return
+	}
+	elapsed := now.Sub(h.periodStart).Seconds()
+	if elapsed < hudPeriod.Seconds() {
+		return
+	}
+	h.turnsPerSecond = float64(h.turn-h.periodTurn) / elapsed
+	h.framesPerSecond = float64(h.periodFrames) / elapsed
+	h.periodStart = now
+	h.periodTurn = h.turn
+	h.periodFrames = 0
+}
+
+//Gives the lines of text shown on the HUD for a world with alive cells
+func (h *hud) lines(alive int) []string {
+	colours := "Colours " + h.palette
+	if h.trails {
+		colours += " trails"
+	}
+	state := "Running"
+	if h.paused {
+		state = "Paused"
+	}
+	return []string{
+		fmt.Sprintf("Turn %v", h.turn),
+		fmt.Sprintf("Alive %v", alive),
+		fmt.Sprintf("TPS %.0f", h.turnsPerSecond),
+		fmt.Sprintf("FPS %.0f", h.framesPerSecond),
+		fmt.Sprintf("Threads %v", h.threads),
+		colours,
+		state,
+	}
+}
+
+//Draws the HUD over the top left corner of the frame in pixels, which is width x height pixels.
+//The text is doubled in size on larger windows.
+func (h *hud) draw(pixels []byte, width, height, alive int) {
+	scale := 1
+	if width >= 640 {
+		scale = 2
+	}
+	lines := h.lines(alive)
+	margin := 4 * scale
+	lineHeight := (glyphHeight + 3) * scale
+
+	boxWidth := 0
+	for _, line := range lines {
+		if lineWidth := textWidth(line, scale); lineWidth > boxWidth {
+			boxWidth = lineWidth
+		}
+	}
+	fillRect(pixels, width, height, 0, 0, boxWidth+2*margin, len(lines)*lineHeight+2*margin-3*scale, hudBoxColour)
+	for i, line := range lines {
+		drawText(pixels, width, height, line, margin, margin+i*lineHeight, scale, hudTextColour)
+	}
+}
@@ -1,17 +1,23 @@
 package SharedSDL
 
 import (
-	"fmt"
 	"github.com/veandco/go-sdl2/sdl"
 	"uk.ac.bris.cs/gameoflife/Distributed/Shared"
+	"uk.ac.bris.cs/gameoflife/logging"
 )
 
+var sdlLog = logging.Component("sdl")
+
 func Run(p Shared.Params, events <-chan Shared.Event, keyPresses chan<- rune) {
 	w := NewWindow(int32(p.ImageWidth), int32(p.ImageHeight))
+	w.hud.threads = p.Threads
 sdlLoop:
 	for {
 		event := w.PollEvent()
 		if event != nil {
+			if w.HandleViewEvent(event) {
+				w.RenderFrame()
+			}
 			switch e := event.(type) {
 			case *sdl.KeyboardEvent:
 				switch e.Keysym.Sym {
@@ -19,16 +25,24 @@ sdlLoop:
 					//When p is pressed, pause the processing and print the current turn that is being processed
 					//If p is pressed again resume the processing
 					keyPresses <- 'p'
-					fmt.Println("P pressed")
+					sdlLog.Debug("Key pressed", "key", "p", "turn", w.hud.turn)
 				case sdl.K_s:
 					//When s is pressed, we need to generate a PGM file with the current state of the board
 					keyPresses <- 's'
 				case sdl.K_q:
 					//When q is pressed, generate a PGM file with the current state of the board then terminate
 					keyPresses <- 'q'
-					fmt.Println("q pressed")
+					sdlLog.Debug("Key pressed", "key", "q", "turn", w.hud.turn)
 				case sdl.K_k:
 					keyPresses <- 'k'
+				case sdl.K_n:
+					//When n is pressed while paused, run exactly one more turn
+					keyPresses <- 'n'
+				case sdl.K_EQUALS, sdl.K_PLUS, sdl.K_KP_PLUS:
+					//+ and - step the target speed up and down, with full speed above the fastest
+					keyPresses <- '+'
+				case sdl.K_MINUS, sdl.K_KP_MINUS:
+					keyPresses <- '-'
 				}
 			}
 		}
@@ -42,14 +56,27 @@ sdlLoop:
 			case Shared.CellFlipped:
 				w.FlipPixel(e.Cell.X, e.Cell.Y)
 			case Shared.TurnComplete:
+				w.AgeCells()
+				w.hud.turn = e.CompletedTurns
+				w.RenderFrame()
+			case Shared.StateChange:
+				w.hud.paused = e.NewState == Shared.Paused
 				w.RenderFrame()
+				sdlLog.Debug("State changed", "turn", e.CompletedTurns, "state", e.NewState)
+			case Shared.AliveCellsCount:
+				//The event carries the cells, so the window is made to match them when the counts differ
+				if w.CheckCount(e.CompletedTurns, e.CellsCount) {
+					w.Resync(e.CompletedTurns, e.Alive)
+					w.RenderFrame()
+				}
 			case Shared.FinalTurnComplete:
+				w.Resync(e.CompletedTurns, e.Alive)
+				w.RenderFrame()
 				w.Destroy()
 				break sdlLoop
 			default:
-				if len(event.String()) > 0 {
-					fmt.Printf("Completed Turns %-8v%v\n", event.GetCompletedTurns(), event)
-				}
+				//The HUD shows the population as it changes, so anything else is only logged
+				sdlLog.Debug("Event", "turn", event.GetCompletedTurns(), "event", event)
 			}
 		default:
 			break
@@ -0,0 +1,76 @@
+package SharedSDL
+
+import "math"
+
+// The age at which cells reach the last colour of a palette, and how many turns a dead cell's trail lasts.
+const (
+	oldAge      = 256
+	trailLength = 16
+)
+
+// palette colours alive cells by how many turns they have been alive for, and dead cells by how recently they died.
+type palette struct {
+	name string
+	// stops are the colours of newborn cells through to cells oldAge turns old, spread evenly on a log scale.
+	stops [][3]byte
+	// trail is the colour of a cell that has just died, which fades to black over trailLength turns.
+	trail   [3]byte
+	colours [oldAge + 1][3]byte
+}
+
+// palettes are the colour schemes the window cycles through with c. The first is the plain black and white.
+var palettes = []*palette{
+	{name: "mono", stops: [][3]byte{{0xFF, 0xFF, 0xFF}}, trail: [3]byte{0x70, 0x70, 0x70}},
+	{name: "heat", stops: [][3]byte{{0xFF, 0xFF, 0xC0}, {0xFF, 0xD0, 0x00}, {0xFF, 0x60, 0x00}, {0xA0, 0x00, 0x00}},
+		trail: [3]byte{0x60, 0x00, 0x80}},
+	{name: "ocean", stops: [][3]byte{{0xC0, 0xFF, 0xFF}, {0x00, 0xC8, 0xFF}, {0x00, 0x5A, 0xDC}, {0x0A, 0x1E, 0x78}},
+		trail: [3]byte{0x00, 0x60, 0x48}},
+	{name: "viridis", stops: [][3]byte{{0xFD, 0xE7, 0x25}, {0x5E, 0xC9, 0x62}, {0x21, 0x91, 0x8C}, {0x3B, 0x52, 0x8B},
+		{0x44, 0x01, 0x54}}, trail: [3]byte{0x50, 0x50, 0x50}},
+}
+
+func init() {
+	for _, p := range palettes {
+		for age := range p.colours {
+			p.colours[age] = p.ramp(math.Log2(float64(1+age)) / math.Log2(1+oldAge))
+		}
+	}
+}
+
+//Gives the colour a fraction t of the way along the palette's stops
+func (p *palette) ramp(t float64) [3]byte {
+	if len(p.stops) == 1 {
+		return p.stops[0]
+	}
+	position := t * float64(len(p.stops)-1)
+	i := int(position)
+	if i >= len(p.stops)-1 {
+		return p.stops[len(p.stops)-1]
+	}
+	fraction := position - float64(i)
+	var colour [3]byte
+	for channel := range colour {
+		from, to := float64(p.stops[i][channel]), float64(p.stops[i+1][channel])
+		colour[channel] = byte(from + (to-from)*fraction + 0.5)
+	}
+	return colour
+}
+
+//Gives the colour of a cell that is alive or not and has been so for age turns.
+//Dead cells are black, or part way through fading from the trail colour when trails are shown.
+func (p *palette) colour(alive bool, age uint16, trails bool) [3]byte {
+	if alive {
+		if age > oldAge {
+			age = oldAge
+		}
+		return p.colours[age]
+	}
+	if !trails || age >= trailLength {
+		return [3]byte{}
+	}
+	var colour [3]byte
+	for channel := range colour {
+		colour[channel] = byte(int(p.trail[channel]) * (trailLength - int(age)) / trailLength)
+	}
+	return colour
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+	"uk.ac.bris.cs/gameoflife/web"
+)
+
+// apiStatus and apiRegion are the replies to GET /status and GET /world.
+type apiStatus struct {
+	Turn  int
+	Alive int
+	State string
+}
+
+type apiRegion struct {
+	Turn  int
+	Cells []string
+}
+
+// TestApi drives the 16x16 game through the REST API: it pauses it, checks the world and a snapshot against each
+// other while it is paused, resumes it, then quits it.
+func TestApi(t *testing.T) {
+	p := gol.Params{Turns: 100000000, Threads: 4, ImageWidth: 16, ImageHeight: 16}
+	commands := make(chan gol.Command, 10)
+	server := web.NewServer(p, commands)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	url := httpServer.URL
+
+	in := make(chan gol.Event, 1000)
+	out := make(chan gol.Event, 1000)
+	go server.Run(in, out)
+	go gol.RunCommands(p, in, commands)
+	done := make(chan bool)
+	go func() {
+		for range out {
+		}
+		done <- true
+	}()
+
+	apiCall(t, http.MethodPost, url+"/pause", http.StatusAccepted, nil)
+	awaitState(t, url, "Paused", -1)
+	//The turn being run when the game was paused finishes first
+	time.Sleep(200 * time.Millisecond)
+	var paused, status apiStatus
+	apiCall(t, http.MethodGet, url+"/status", http.StatusOK, &paused)
+	time.Sleep(200 * time.Millisecond)
+	apiCall(t, http.MethodGet, url+"/status", http.StatusOK, &status)
+	if status.Turn != paused.Turn {
+		t.Errorf("Expected the turn to stay at %v while paused, got %v", paused.Turn, status.Turn)
+	}
+
+	var world apiRegion
+	apiCall(t, http.MethodGet, url+"/world", http.StatusOK, &world)
+	if len(world.Cells) != 16 || world.Turn != paused.Turn {
+		t.Fatalf("Expected 16 rows at turn %v, got %v at turn %v", paused.Turn, len(world.Cells), world.Turn)
+	}
+	var alive []util.Cell
+	for y, row := range world.Cells {
+		for x, cell := range row {
+			if cell == 'O' {
+				alive = append(alive, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	if len(alive) != paused.Alive {
+		t.Errorf("Expected %v alive cells in the world, got %v", paused.Alive, len(alive))
+	}
+
+	var part apiRegion
+	apiCall(t, http.MethodGet, url+"/world?x=4&y=2&w=5&h=3", http.StatusOK, &part)
+	for i, row := range part.Cells {
+		if row != world.Cells[2+i][4:9] {
+			t.Errorf("Expected row %v of the region to be %v, got %v", i, world.Cells[2+i][4:9], row)
+		}
+	}
+	apiCall(t, http.MethodGet, url+"/world?x=14&w=5", http.StatusBadRequest, nil)
+	apiCall(t, http.MethodGet, url+"/pause", http.StatusMethodNotAllowed, nil)
+
+	response, err := http.Post(url+"/snapshot", "", nil)
+	util.Check(err)
+	image, err := ioutil.ReadAll(response.Body)
+	util.Check(err)
+	util.Check(response.Body.Close())
+	if response.StatusCode != http.StatusOK || !strings.HasPrefix(string(image), "P5") {
+		t.Fatalf("Expected a PGM image from /snapshot, got %v", response.Status)
+	}
+	if contentType := response.Header.Get("Content-Type"); contentType != "image/x-portable-graymap" {
+		t.Errorf("Expected the snapshot's content type to be image/x-portable-graymap, got %v", contentType)
+	}
+	if turn := response.Header.Get("X-Turn"); turn != strconv.Itoa(paused.Turn) {
+		t.Errorf("Expected the snapshot to be of turn %v, got %v", paused.Turn, turn)
+	}
+	saved := readAliveCells("out/16x16x"+strconv.Itoa(paused.Turn)+".pgm", 16, 16)
+	assertEqualBoard(t, saved, alive, p)
+
+	apiCall(t, http.MethodPost, url+"/resume", http.StatusAccepted, nil)
+	awaitState(t, url, "Executing", paused.Turn)
+
+	apiCall(t, http.MethodPost, url+"/quit", http.StatusAccepted, nil)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the game to quit")
+	}
+	apiCall(t, http.MethodPost, url+"/pause", http.StatusConflict, nil)
+}
+
+// TestApiFormats saves snapshots of the 16x16 game as PNG and RLE through the API, checking each is sent with the
+// content type of its format.
+func TestApiFormats(t *testing.T) {
+	formats := []struct {
+		format, contentType, prefix string
+	}{
+		{util.FormatPNG, "image/png", "\x89PNG"},
+		{util.FormatRLE, "application/octet-stream", "x = 16, y = 16"},
+	}
+	for _, f := range formats {
+		t.Run(f.format, func(t *testing.T) {
+			p := gol.Params{Turns: 100000000, Threads: 4, ImageWidth: 16, ImageHeight: 16, OutputFormat: f.format}
+			commands := make(chan gol.Command, 10)
+			server := web.NewServer(p, commands)
+			httpServer := httptest.NewServer(server)
+			defer httpServer.Close()
+
+			in := make(chan gol.Event, 1000)
+			out := make(chan gol.Event, 1000)
+			go server.Run(in, out)
+			go gol.RunCommands(p, in, commands)
+			go func() {
+				for range out {
+				}
+			}()
+
+			response, err := http.Post(httpServer.URL+"/snapshot", "", nil)
+			util.Check(err)
+			image, err := ioutil.ReadAll(response.Body)
+			util.Check(err)
+			util.Check(response.Body.Close())
+			defer os.Remove("out/16x16x" + response.Header.Get("X-Turn") + util.Extension(f.format))
+			if response.StatusCode != http.StatusOK || !strings.HasPrefix(string(image), f.prefix) {
+				t.Errorf("Expected a %v file from /snapshot, got %v", f.format, response.Status)
+			}
+			if contentType := response.Header.Get("Content-Type"); contentType != f.contentType {
+				t.Errorf("Expected the content type to be %v, got %v", f.contentType, contentType)
+			}
+
+			apiCall(t, http.MethodPost, httpServer.URL+"/quit", http.StatusAccepted, nil)
+			awaitState(t, httpServer.URL, "Finished", -1)
+			var quit apiStatus
+			apiCall(t, http.MethodGet, httpServer.URL+"/status", http.StatusOK, &quit)
+			defer os.Remove("out/16x16x" + strconv.Itoa(quit.Turn) + util.Extension(f.format))
+			defer os.Remove("out/16x16x" + strconv.Itoa(quit.Turn) + ".checkpoint")
+		})
+	}
+}
+
+// TestApiCommandTimeout checks a control request gives up with 503 Service Unavailable when nothing takes its
+// command, rather than waiting forever.
+func TestApiCommandTimeout(t *testing.T) {
+	p := gol.Params{Turns: 100, Threads: 1, ImageWidth: 16, ImageHeight: 16}
+	server := web.NewServer(p, make(chan gol.Command))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	in := make(chan gol.Event)
+	defer close(in)
+	go server.Run(in, make(chan gol.Event, 1))
+
+	start := time.Now()
+	apiCall(t, http.MethodPost, httpServer.URL+"/pause", http.StatusServiceUnavailable, nil)
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("Expected the request to give up within 5s, it took %v", waited)
+	}
+}
+
+//Makes a request to the API, checks its status code and decodes its JSON reply into reply if it isn't nil
+func apiCall(t *testing.T, method, url string, code int, reply interface{}) {
+	request, err := http.NewRequest(method, url, nil)
+	util.Check(err)
+	response, err := http.DefaultClient.Do(request)
+	util.Check(err)
+	defer response.Body.Close()
+	if response.StatusCode != code {
+		t.Fatalf("Expected %v %v to give %v, got %v", method, url, code, response.Status)
+	}
+	if reply != nil {
+		util.Check(json.NewDecoder(response.Body).Decode(reply))
+	}
+}
+
+//Polls GET /status until the game is in the state and past the turn, failing if it takes too long
+func awaitState(t *testing.T, url, state string, pastTurn int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var status apiStatus
+		apiCall(t, http.MethodGet, url+"/status", http.StatusOK, &status)
+		if status.State == state && status.Turn > pastTurn {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the game to be %v past turn %v, got %+v", state, pastTurn, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestBench sweeps a small random world with two thread counts, checks the summary of known times, that results
+// written as CSV read back the same, and that a run slower than the threshold is flagged as a regression.
+func TestBench(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bench")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+	//The engine saves the final world in out/, which is best kept out of the repository
+	working, err := os.Getwd()
+	util.Check(err)
+	util.Check(os.Chdir(dir))
+	defer os.Chdir(working)
+
+	s := sweep{engines: []string{engineLocal}, rules: []string{"B3/S23"}, sizes: []util.Cell{{X: 24, Y: 10}},
+		threads: []int{1, 3}, turns: 10, repeats: 2, seed: 1, dir: dir}
+	results := s.run()
+	if len(results) != 2 || results[1].Threads != 3 || results[1].Repeats != 2 || results[1].MeanSeconds <= 0 {
+		t.Fatalf("Expected a result for each thread count, got %+v", results)
+	}
+
+	r := summarise(key{Turns: 10}, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second})
+	if r.MeanSeconds != 2 || r.StddevSeconds != 1 || r.MinSeconds != 1 || r.MaxSeconds != 3 || r.TurnsPerSecond != 5 {
+		t.Errorf("Expected a mean of 2s, deviation of 1s and 5 turns per second, got %+v", r)
+	}
+	if expected := 4.303 / math.Sqrt(3); math.Abs(r.CI95Seconds-expected) > 1e-9 {
+		t.Errorf("Expected a confidence interval of ±%v, got ±%v", expected, r.CI95Seconds)
+	}
+
+	path := filepath.Join(dir, "results.csv")
+	var written bytes.Buffer
+	util.Check(writeCSV(&written, results))
+	util.Check(ioutil.WriteFile(path, written.Bytes(), 0644))
+	read, err := readResults(path)
+	util.Check(err)
+	if !reflect.DeepEqual(read, results) {
+		t.Errorf("Expected the CSV to read back as\n%+v\ngot\n%+v", results, read)
+	}
+
+	slower := append([]result(nil), results...)
+	slower[0].MeanSeconds *= 1.5
+	comparisons := compareResults(results, slower, 0.1)
+	if len(comparisons) != 2 || !comparisons[0].regression || comparisons[1].regression {
+		t.Errorf("Expected only the first run to be flagged, got %+v", comparisons)
+	}
+	var table bytes.Buffer
+	writeComparison(&table, comparisons, 0.1)
+	if !strings.Contains(table.String(), "+50.0%") || !strings.Contains(table.String(), "1 of 2 runs") {
+		t.Errorf("Expected the regression in the comparison, got\n%v", table.String())
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/Distributed/Shared"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// The engines a sweep can measure.
+const (
+	engineLocal  = "local"
+	engineBroker = "broker"
+)
+
+//Measures every combination in the sweep, giving a result for each
+func (s sweep) run() []result {
+	var results []result
+	for _, engine := range s.engines {
+		if engine != engineLocal && engine != engineBroker {
+			panic(fmt.Sprintf("Unknown engine %v", engine))
+		}
+		for _, rule := range s.rules {
+			_, err := gol.ParseRule(rule)
+			util.Check(err)
+			if engine == engineBroker && rule != "B3/S23" {
+				benchLog.Warn("The broker only runs B3/S23, skipping", "rule", rule)
+				continue
+			}
+			for _, size := range s.sizes {
+				world := s.worldFile(size)
+				threads := s.threads
+				if engine == engineBroker {
+					//The broker splits the world between its nodes however many threads are asked for
+					threads = []int{0}
+				}
+				for _, thread := range threads {
+					key := key{Engine: engine, Rule: rule, Width: size.X, Height: size.Y, Threads: thread,
+						Turns: s.turns}
+					times := make([]time.Duration, s.repeats)
+					for i := range times {
+						if engine == engineLocal {
+							times[i] = runLocal(key, world)
+						} else {
+							times[i] = runBroker(key, world, s.broker)
+						}
+					}
+					r := summarise(key, times)
+					benchLog.Info("Measured", "engine", engine, "rule", rule, "size", fmt.Sprintf("%vx%v", size.X, size.Y),
+						"threads", thread, "mean", time.Duration(r.MeanSeconds*float64(time.Second)),
+						"ci95", time.Duration(r.CI95Seconds*float64(time.Second)))
+					results = append(results, r)
+				}
+			}
+		}
+	}
+	return results
+}
+
+//Gives the image to start from for the size, making a random world if there isn't one in images/
+func (s sweep) worldFile(size util.Cell) string {
+	image := fmt.Sprintf("images/%vx%v.pgm", size.X, size.Y)
+	if _, err := os.Stat(image); err == nil {
+		return image
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("%vx%v.pgm", size.X, size.Y))
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	benchLog.Info("Making a random world", "size", fmt.Sprintf("%vx%v", size.X, size.Y), "seed", s.seed)
+	file, err := os.Create(path)
+	util.Check(err)
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	_, err = fmt.Fprintf(writer, "P5\n%v %v\n255\n", size.X, size.Y)
+	util.Check(err)
+	random := rand.New(rand.NewSource(s.seed))
+	for i := 0; i < size.X*size.Y; i++ {
+		var pixel byte
+		if random.Intn(2) == 0 {
+			pixel = 255
+		}
+		util.Check(writer.WriteByte(pixel))
+	}
+	util.Check(writer.Flush())
+	return path
+}
+
+//Runs the game in this process, timing it from the start until the engine has shut down
+func runLocal(k key, world string) time.Duration {
+	p := gol.Params{Turns: k.Turns, Threads: k.Threads, ImageWidth: k.Width, ImageHeight: k.Height, Rule: k.Rule,
+		InputFile: world}
+	events := make(chan gol.Event, 1000)
+	start := time.Now()
+	go gol.Run(p, events, nil)
+	for range events {
+	}
+	return time.Since(start)
+}
+
+//Runs the game on the broker, timing it from sending the world until the final one comes back
+func runBroker(k key, world, address string) time.Duration {
+	file, err := os.Open(world)
+	util.Check(err)
+	_, cells, err := util.ReadPNM(file, util.DefaultThreshold)
+	util.Check(file.Close())
+	util.Check(err)
+
+	client := Shared.HandleCreateClientAndError(address)
+	defer client.Close()
+	request := Shared.Request{
+		World:      cells,
+		Parameters: Shared.Params{Turns: k.Turns, ImageWidth: k.Width, ImageHeight: k.Height},
+	}
+	response := new(Shared.Response)
+	start := time.Now()
+	Shared.HandleCallAndError(client, Shared.BrokerHandler, &request, response)
+	return time.Since(start)
+}
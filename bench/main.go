@@ -0,0 +1,187 @@
+// Command bench runs scaling experiments on the Game of Life, sweeping thread counts, world sizes, engines and rules,
+// repeating each run and reporting the mean time with a 95% confidence interval as CSV and JSON.
+// Given the results of an earlier sweep with -compare, it flags the runs that have slowed down by more than
+// -threshold and exits with status 1 if there are any, so it can be used to catch regressions.
+//
+// Run it from the root of the repository, so that the engine finds images/, e.g.
+//
+//	go run ./bench -threads 1,2,4,8 -sizes 64x64,512x512 -turns 100 -repeats 5 -csv new.csv -compare old.csv
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/logging"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+var benchLog = logging.Component("bench")
+
+// sweep is every combination of settings to measure.
+type sweep struct {
+	engines []string
+	rules   []string
+	sizes   []util.Cell
+	threads []int
+	turns   int
+	repeats int
+	//broker is the address of the broker, for the broker engine
+	broker string
+	//seed makes the random worlds used for sizes without an image the same every time
+	seed int64
+	//dir holds the random worlds
+	dir string
+}
+
+func main() {
+	engines := flag.String(
+		"engines",
+		"local",
+		"Specify the engines to measure, comma separated: local, which runs in this process, or broker, which runs "+
+			"on the broker at -broker with the rule B3/S23. Defaults to local.")
+
+	broker := flag.String(
+		"broker",
+		"127.0.0.1:8030",
+		"Specify the address of the broker, for the broker engine. Defaults to 127.0.0.1:8030.")
+
+	threads := flag.String(
+		"threads",
+		"1,2,4,8,16",
+		"Specify the numbers of worker threads to measure, comma separated. Defaults to 1,2,4,8,16.")
+
+	sizes := flag.String(
+		"sizes",
+		"512x512",
+		"Specify the world sizes to measure as WxH, comma separated. Sizes without an image in images/ start from "+
+			"a random world. Defaults to 512x512.")
+
+	rules := flag.String(
+		"rules",
+		"B3/S23",
+		"Specify the rules to measure in B/S notation, comma separated. Defaults to B3/S23.")
+
+	turns := flag.Int(
+		"turns",
+		1000,
+		"Specify the number of turns in each run. Defaults to 1000.")
+
+	repeats := flag.Int(
+		"repeats",
+		5,
+		"Specify how many times to repeat each run. Defaults to 5.")
+
+	seed := flag.Int64(
+		"seed",
+		1,
+		"Specify the seed of the random worlds. Defaults to 1.")
+
+	csvFile := flag.String(
+		"csv",
+		"",
+		"Write the results as CSV to the given file. Defaults to stdout.")
+
+	jsonFile := flag.String(
+		"json",
+		"",
+		"Write the results, and the machine they were measured on, as JSON to the given file. Disabled by default.")
+
+	compare := flag.String(
+		"compare",
+		"",
+		"Compare against the results in an earlier .csv or .json file, flagging any regressions. Disabled by default.")
+
+	threshold := flag.Float64(
+		"threshold",
+		0.1,
+		"Specify how much slower, as a fraction, a run must be than before to be flagged as a regression. "+
+			"Defaults to 0.1.")
+
+	logFlags := logging.AddFlags()
+
+	flag.Parse()
+	logFlags.Setup("bench")
+
+	dir, err := ioutil.TempDir("", "bench")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+	s := sweep{
+		engines: strings.Split(*engines, ","),
+		rules:   strings.Split(*rules, ","),
+		sizes:   parseSizes(*sizes),
+		threads: parseInts(*threads),
+		turns:   *turns,
+		repeats: *repeats,
+		broker:  *broker,
+		seed:    *seed,
+		dir:     dir,
+	}
+	if s.repeats < 1 || s.turns < 1 {
+		panic("Each run needs at least 1 turn and 1 repeat")
+	}
+
+	var previous []result
+	if *compare != "" {
+		previous, err = readResults(*compare)
+		util.Check(err)
+	}
+
+	results := s.run()
+
+	if *csvFile == "" {
+		util.Check(writeCSV(os.Stdout, results))
+	} else {
+		file, err := os.Create(*csvFile)
+		util.Check(err)
+		util.Check(writeCSV(file, results))
+		util.Check(file.Close())
+	}
+	if *jsonFile != "" {
+		file, err := os.Create(*jsonFile)
+		util.Check(err)
+		util.Check(writeJSON(file, report{
+			Go: runtime.Version(), OS: runtime.GOOS, Arch: runtime.GOARCH, CPUs: runtime.NumCPU(),
+			Time: time.Now().UTC().Format(time.RFC3339), Results: results}))
+		util.Check(file.Close())
+	}
+
+	if *compare != "" {
+		comparisons := compareResults(previous, results, *threshold)
+		writeComparison(os.Stderr, comparisons, *threshold)
+		for _, comparison := range comparisons {
+			if comparison.regression {
+				os.Exit(1)
+			}
+		}
+	}
+}
+
+//Reads sizes given as WxH
+func parseSizes(text string) []util.Cell {
+	var sizes []util.Cell
+	for _, size := range strings.Split(text, ",") {
+		var cell util.Cell
+		if _, err := fmt.Sscanf(size, "%dx%d", &cell.X, &cell.Y); err != nil || cell.X < 1 || cell.Y < 1 {
+			panic(fmt.Sprintf("Expected a size as WxH, got %q", size))
+		}
+		sizes = append(sizes, cell)
+	}
+	return sizes
+}
+
+func parseInts(text string) []int {
+	var values []int
+	for _, value := range strings.Split(text, ",") {
+		number, err := strconv.Atoi(value)
+		util.Check(err)
+		values = append(values, number)
+	}
+	return values
+}
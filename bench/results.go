@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// key is what a run measured, which matches it up with the same run in earlier results.
+type key struct {
+	Engine  string `json:"engine"`
+	Rule    string `json:"rule"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Threads int    `json:"threads"`
+	Turns   int    `json:"turns"`
+}
+
+// result is how long a run took over its repeats.
+// CI95Seconds is the half width of the 95% confidence interval of the mean, and 0 with a single repeat.
+type result struct {
+	key
+	Repeats        int     `json:"repeats"`
+	MeanSeconds    float64 `json:"mean_seconds"`
+	CI95Seconds    float64 `json:"ci95_seconds"`
+	StddevSeconds  float64 `json:"stddev_seconds"`
+	MinSeconds     float64 `json:"min_seconds"`
+	MaxSeconds     float64 `json:"max_seconds"`
+	TurnsPerSecond float64 `json:"turns_per_second"`
+}
+
+// report is the JSON output: the results and the machine they were measured on.
+type report struct {
+	Go      string   `json:"go"`
+	OS      string   `json:"os"`
+	Arch    string   `json:"arch"`
+	CPUs    int      `json:"cpus"`
+	Time    string   `json:"time"`
+	Results []result `json:"results"`
+}
+
+// csvHeader is the first row of the CSV output.
+var csvHeader = []string{"engine", "rule", "width", "height", "threads", "turns", "repeats", "mean_seconds",
+	"ci95_seconds", "stddev_seconds", "min_seconds", "max_seconds", "turns_per_second"}
+
+// tValues are Student's t for a two sided 95% interval with 1 to 30 degrees of freedom. Beyond them it is 1.96.
+var tValues = []float64{12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228, 2.201, 2.179, 2.160,
+	2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086, 2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045,
+	2.042}
+
+//Works out the mean, spread and confidence interval of the times
+func summarise(k key, times []time.Duration) result {
+	r := result{key: k, Repeats: len(times), MinSeconds: math.Inf(1)}
+	for _, t := range times {
+		seconds := t.Seconds()
+		r.MeanSeconds += seconds
+		r.MinSeconds = math.Min(r.MinSeconds, seconds)
+		r.MaxSeconds = math.Max(r.MaxSeconds, seconds)
+	}
+	r.MeanSeconds /= float64(len(times))
+	if len(times) > 1 {
+		var squares float64
+		for _, t := range times {
+			squares += (t.Seconds() - r.MeanSeconds) * (t.Seconds() - r.MeanSeconds)
+		}
+		r.StddevSeconds = math.Sqrt(squares / float64(len(times)-1))
+		t := 1.96
+		if len(times)-1 <= len(tValues) {
+			t = tValues[len(times)-2]
+		}
+		r.CI95Seconds = t * r.StddevSeconds / math.Sqrt(float64(len(times)))
+	}
+	if r.MeanSeconds > 0 {
+		r.TurnsPerSecond = float64(k.Turns) / r.MeanSeconds
+	}
+	return r
+}
+
+func writeCSV(w io.Writer, results []result) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range results {
+		row := []string{r.Engine, r.Rule}
+		for _, value := range []int{r.Width, r.Height, r.Threads, r.Turns, r.Repeats} {
+			row = append(row, strconv.Itoa(value))
+		}
+		for _, value := range []float64{r.MeanSeconds, r.CI95Seconds, r.StddevSeconds, r.MinSeconds, r.MaxSeconds,
+			r.TurnsPerSecond} {
+			row = append(row, strconv.FormatFloat(value, 'g', -1, 64))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeJSON(w io.Writer, r report) error {
+	encoded, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(encoded, '\n'))
+	return err
+}
+
+//Reads results written by an earlier sweep, as JSON if the file ends in .json and as CSV otherwise
+func readResults(path string) ([]result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		var r report
+		if err := json.NewDecoder(file).Decode(&r); err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		return r.Results, nil
+	}
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	if len(rows) == 0 || strings.Join(rows[0], ",") != strings.Join(csvHeader, ",") {
+		return nil, fmt.Errorf("%v is not a results file written by bench", path)
+	}
+	var results []result
+	for line, row := range rows[1:] {
+		r := result{key: key{Engine: row[0], Rule: row[1]}}
+		ints := []*int{&r.Width, &r.Height, &r.Threads, &r.Turns, &r.Repeats}
+		floats := []*float64{&r.MeanSeconds, &r.CI95Seconds, &r.StddevSeconds, &r.MinSeconds, &r.MaxSeconds,
+			&r.TurnsPerSecond}
+		for i, value := range ints {
+			if *value, err = strconv.Atoi(row[2+i]); err != nil {
+				return nil, fmt.Errorf("%v line %v: %v", path, line+2, err)
+			}
+		}
+		for i, value := range floats {
+			if *value, err = strconv.ParseFloat(row[2+len(ints)+i], 64); err != nil {
+				return nil, fmt.Errorf("%v line %v: %v", path, line+2, err)
+			}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// comparison is a result next to the same run's earlier result.
+type comparison struct {
+	before, after result
+	//change is how much slower the run has got, as a fraction of the time it took before
+	change     float64
+	regression bool
+}
+
+//Matches each result with the same run in previous, flagging those that have slowed down by more than threshold
+func compareResults(previous, results []result, threshold float64) []comparison {
+	earlier := make(map[key]result)
+	for _, r := range previous {
+		earlier[r.key] = r
+	}
+	var comparisons []comparison
+	for _, r := range results {
+		before, ok := earlier[r.key]
+		if !ok || before.MeanSeconds <= 0 {
+			continue
+		}
+		change := r.MeanSeconds/before.MeanSeconds - 1
+		comparisons = append(comparisons, comparison{before: before, after: r, change: change,
+			regression: change > threshold})
+	}
+	return comparisons
+}
+
+//Writes a row for each comparison, marking the regressions
+func writeComparison(w io.Writer, comparisons []comparison, threshold float64) {
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "engine\trule\tsize\tthreads\tturns\tbefore\tafter\tchange\t")
+	regressions := 0
+	for _, c := range comparisons {
+		mark := ""
+		if c.regression {
+			mark = "REGRESSION"
+			regressions++
+		}
+		_, _ = fmt.Fprintf(table, "%v\t%v\t%vx%v\t%v\t%v\t%.4fs ± %.4f\t%.4fs ± %.4f\t%+.1f%%\t%v\n",
+			c.after.Engine, c.after.Rule, c.after.Width, c.after.Height, c.after.Threads, c.after.Turns,
+			c.before.MeanSeconds, c.before.CI95Seconds, c.after.MeanSeconds, c.after.CI95Seconds, c.change*100, mark)
+	}
+	_ = table.Flush()
+	_, _ = fmt.Fprintf(w, "%v of %v runs compared are more than %.0f%% slower than before\n", regressions,
+		len(comparisons), threshold*100)
+}
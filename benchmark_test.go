@@ -2,9 +2,11 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"testing"
 	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/logging"
 )
 
 // Benchmark applies the filter to the ship.png b.N times.
@@ -13,6 +15,7 @@ import (
 func BenchmarkFilter(b *testing.B) {
 	// Disable all program output apart from benchmark results
 	os.Stdout = nil
+	logging.SetDefault(logging.New(ioutil.Discard, logging.LevelError, false))
 
 	// Use a for-loop to run 5 sub-benchmarks, with 1, 2, 4, 8 and 16 workers.
 	for threads := 1; threads <= 16; threads *= 2 {
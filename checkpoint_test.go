@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestCheckpoint saves the 16x16 world after 1 turn as a checkpoint, checks its turns and threads are used unless
+// others are given, resumes it up to turn 100 and checks the turn numbers carry on from the checkpoint and the final
+// world matches the 100 turn image. The seed must survive being saved, read and saved again on quitting.
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+
+	file, err := os.Open("check/images/16x16x1.pgm")
+	util.Check(err)
+	_, world, err := util.ReadPNM(file, util.DefaultThreshold)
+	util.Check(err)
+	util.Check(file.Close())
+
+	saved := gol.Params{Turns: 1000, Threads: 8, ImageWidth: 16, ImageHeight: 16}
+	path := filepath.Join(dir, "16x16x1.checkpoint")
+	util.Check(gol.WriteCheckpoint(path, gol.Checkpoint{Turn: 1, Params: saved, Rule: gol.ConwayRule, Seed: 42,
+		World: world}))
+
+	loaded, err := gol.ReadCheckpoint(path)
+	util.Check(err)
+	if loaded.Turn != 1 || loaded.Rule != gol.ConwayRule || loaded.Params.Turns != saved.Turns || loaded.Seed != 42 {
+		t.Fatalf("Expected turn 1 of %v running %v with seed 42, got turn %v of %v running %v with seed %v",
+			saved.Turns, gol.ConwayRule, loaded.Turn, loaded.Params.Turns, loaded.Rule, loaded.Seed)
+	}
+
+	carried := gol.ResolveParams(gol.Params{ResumeFile: path})
+	if carried.Turns != saved.Turns || carried.Threads != saved.Threads {
+		t.Errorf("Expected the checkpoint's %v turns on %v threads, got %v on %v",
+			saved.Turns, saved.Threads, carried.Turns, carried.Threads)
+	}
+
+	p := gol.Params{Turns: 100, Threads: 4, ResumeFile: path}
+	resolved := gol.ResolveParams(p)
+	if resolved.ImageWidth != 16 || resolved.ImageHeight != 16 || resolved.Turns != 100 || resolved.Threads != 4 {
+		t.Fatalf("Expected a 16x16 world for 100 turns on 4 threads, got %vx%v for %v on %v",
+			resolved.ImageWidth, resolved.ImageHeight, resolved.Turns, resolved.Threads)
+	}
+
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	firstTurn := -1
+	var final gol.FinalTurnComplete
+	for event := range events {
+		switch e := event.(type) {
+		case gol.TurnComplete:
+			if firstTurn < 0 {
+				firstTurn = e.CompletedTurns
+			}
+		case gol.FinalTurnComplete:
+			final = e
+		}
+	}
+	if firstTurn != 2 {
+		t.Errorf("Expected the first turn completed after resuming to be 2, got %v", firstTurn)
+	}
+	if final.CompletedTurns != 100 {
+		t.Errorf("Expected the run to finish at turn 100, got %v", final.CompletedTurns)
+	}
+	assertEqualBoard(t, final.Alive, readAliveCells("check/images/16x16x100.pgm", 16, 16), resolved)
+
+	//Quitting a resumed run saves a checkpoint with the same seed
+	keyPresses := make(chan rune, 1)
+	keyPresses <- 'q'
+	events = make(chan gol.Event)
+	go gol.Run(p, events, keyPresses)
+	quitTurn := -1
+	for event := range events {
+		if e, ok := event.(gol.StateChange); ok && e.NewState == gol.Quitting {
+			quitTurn = e.CompletedTurns
+		}
+	}
+	quitPath := fmt.Sprintf("out/16x16x%v.checkpoint", quitTurn)
+	defer os.Remove(quitPath)
+	quitted, err := gol.ReadCheckpoint(quitPath)
+	util.Check(err)
+	if quitted.Seed != 42 || quitted.Turn != quitTurn {
+		t.Errorf("Expected the checkpoint saved on quitting to be turn %v with seed 42, got turn %v with seed %v",
+			quitTurn, quitted.Turn, quitted.Seed)
+	}
+}
@@ -69,7 +69,12 @@ func TestAlive(t *testing.T) {
 }
 
 func readAliveCounts(width, height int) map[int]int {
-	f, err := os.Open("check/alive/" + fmt.Sprintf("%vx%v.csv", width, height))
+	return readAliveCountsFile("check/alive/" + fmt.Sprintf("%vx%v.csv", width, height))
+}
+
+//Reads the alive cells after each turn from a CSV in check/alive
+func readAliveCountsFile(path string) map[int]int {
+	f, err := os.Open(path)
 	util.Check(err)
 	reader := csv.NewReader(f)
 	table, err := reader.ReadAll()
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestDimensions checks the world is sized from the input file when no size is given,
+// that a file smaller than the world asked for is placed in it at the input offset, that there are never more
+// threads than rows, and that a missing input file is reported by the name it was given.
+func TestDimensions(t *testing.T) {
+	t.Run("from file", func(t *testing.T) {
+		p := gol.ResolveParams(gol.Params{Turns: 1, Threads: 4, InputFile: "images/16x16.pgm"})
+		if p.ImageWidth != 16 || p.ImageHeight != 16 {
+			t.Fatalf("Expected a 16x16 world, got %vx%v", p.ImageWidth, p.ImageHeight)
+		}
+
+		events := make(chan gol.Event)
+		go gol.Run(gol.Params{Turns: 1, Threads: 4, InputFile: "images/16x16.pgm"}, events, nil)
+		var cells []util.Cell
+		for event := range events {
+			switch e := event.(type) {
+			case gol.FinalTurnComplete:
+				cells = e.Alive
+			}
+		}
+		assertEqualBoard(t, cells, readAliveCells("check/images/16x16x1.pgm", 16, 16), p)
+	})
+
+	t.Run("embedded", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "dimensions")
+		util.Check(err)
+		defer os.RemoveAll(dir)
+
+		input := filepath.Join(dir, "glider.pbm")
+		util.Check(ioutil.WriteFile(input, []byte("P1\n3 3\n010\n001\n111\n"), 0644))
+
+		p := gol.Params{Turns: 4, Threads: 2, ImageWidth: 16, ImageHeight: 16, InputFile: input, InputOffset: &util.Cell{}}
+		events := make(chan gol.Event)
+		go gol.Run(p, events, nil)
+		var cells []util.Cell
+		for event := range events {
+			switch e := event.(type) {
+			case gol.FinalTurnComplete:
+				cells = e.Alive
+			}
+		}
+		expected := []util.Cell{{X: 2, Y: 1}, {X: 3, Y: 2}, {X: 1, Y: 3}, {X: 2, Y: 3}, {X: 3, Y: 3}}
+		assertEqualBoard(t, cells, expected, p)
+	})
+
+	t.Run("more threads than rows", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "dimensions")
+		util.Check(err)
+		defer os.RemoveAll(dir)
+
+		input := filepath.Join(dir, "glider.pbm")
+		util.Check(ioutil.WriteFile(input, []byte("P1\n3 3\n010\n001\n111\n"), 0644))
+
+		p := gol.ResolveParams(gol.Params{Turns: 4, Threads: 8, InputFile: input})
+		if p.ImageWidth != 3 || p.ImageHeight != 3 || p.Threads != 3 {
+			t.Fatalf("Expected a 3x3 world with 3 threads, got %vx%v with %v", p.ImageWidth, p.ImageHeight, p.Threads)
+		}
+		events := make(chan gol.Event)
+		go gol.Run(gol.Params{Turns: 4, Threads: 8, InputFile: input}, events, nil)
+		for event := range events {
+			if e, ok := event.(gol.FinalTurnComplete); ok && e.CompletedTurns != 4 {
+				t.Errorf("Expected 4 turns to complete, got %v", e.CompletedTurns)
+			}
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			if err == nil || !os.IsNotExist(err) || !strings.Contains(err.Error(), "missing.pgm") {
+				t.Errorf("Expected the missing file to be reported, got %v", err)
+			}
+		}()
+		gol.ResolveParams(gol.Params{Turns: 1, Threads: 4, InputFile: "missing.pgm"})
+	})
+}
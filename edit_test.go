@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestEdit starts from an empty 16x16 world, draws a block in it with an EditCells command and quits once the edit
+// has been made, checking that the block is in the world saved on quitting.
+func TestEdit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edit")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+
+	empty := make([][]byte, 16)
+	for i := range empty {
+		empty[i] = make([]byte, 16)
+	}
+	p := gol.Params{Turns: 100000000, Threads: 4, ImageWidth: 16, ImageHeight: 16}
+	path := filepath.Join(dir, "empty.checkpoint")
+	util.Check(gol.WriteCheckpoint(path, gol.Checkpoint{Params: p, Rule: gol.ConwayRule, World: empty}))
+	p.ResumeFile = path
+
+	events := make(chan gol.Event, 1000)
+	commands := make(chan gol.Command, 2)
+	go gol.RunCommands(p, events, commands)
+
+	block := []util.Cell{{X: 4, Y: 4}, {X: 5, Y: 4}, {X: 4, Y: 5}, {X: 5, Y: 5}}
+	commands <- gol.EditCells{Cells: block, Alive: true}
+	quitTurn := -1
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellsEdited:
+			commands <- gol.KeyPress('q')
+		case gol.StateChange:
+			if e.NewState == gol.Quitting {
+				quitTurn = e.CompletedTurns
+			}
+		}
+	}
+	if quitTurn < 0 {
+		t.Fatal("The game finished without quitting")
+	}
+
+	filename := "out/16x16x" + strconv.Itoa(quitTurn)
+	defer os.Remove(filename + ".pgm")
+	defer os.Remove(filename + ".checkpoint")
+	assertEqualBoard(t, readAliveCells(filename+".pgm", 16, 16), block, p)
+}
@@ -0,0 +1,327 @@
+// Package eventlog records the events of a run to a compact binary log, and replays a log into anything that takes
+// events, such as the SDL window, the terminal renderer or a GIF recording, without working the turns out again.
+//
+// A log starts with "GOLLOG", a version byte and the run's width, height, threads and turns. Each event after it is
+// a kind byte, the change in completed turns since the last event as a signed varint, and the event's own fields.
+// A flipped cell is stored as how far along the world it is from the cell after the last one flipped, so the cells
+// of a turn, which come in order, mostly take a byte each.
+package eventlog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+const magic = "GOLLOG"
+const version = 1
+
+// The kinds of event a log holds.
+const (
+	kindCellFlipped byte = iota + 1
+	kindTurnComplete
+	kindFinalTurnComplete
+	kindStateChange
+	kindAliveCellsCount
+	kindImageOutputComplete
+	kindCellsEdited
+	kindRateChanged
+	kindStats
+)
+
+// Header describes the run a log was recorded from.
+type Header struct {
+	Width, Height int
+	Threads       int
+	Turns         int
+}
+
+// Writer writes events to a log.
+type Writer struct {
+	w      *bufio.Writer
+	header Header
+	turn   int
+	//last is the index in the world of the last cell flipped, or -1 after any other event
+	last  int
+	start time.Time
+	//elapsed is how long into the run the last TurnComplete came
+	elapsed time.Duration
+}
+
+// NewWriter writes the header to w and gives a Writer for the events that follow it.
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	writer := &Writer{w: bufio.NewWriterSize(w, 64*1024), header: header, last: -1, start: time.Now()}
+	writer.w.WriteString(magic)
+	writer.w.WriteByte(version)
+	for _, value := range []int{header.Width, header.Height, header.Threads, header.Turns} {
+		writer.putUvarint(value)
+	}
+	return writer, writer.Flush()
+}
+
+// Write adds an event to the log. Events of types the log doesn't know are left out.
+func (w *Writer) Write(event gol.Event) error {
+	switch e := event.(type) {
+	case gol.CellFlipped:
+		w.begin(kindCellFlipped, e.CompletedTurns)
+		w.putCell(e.Cell)
+	case gol.TurnComplete:
+		w.begin(kindTurnComplete, e.CompletedTurns)
+		elapsed := time.Since(w.start)
+		w.putUvarint(int((elapsed - w.elapsed) / time.Microsecond))
+		w.elapsed = elapsed
+	case gol.FinalTurnComplete:
+		w.begin(kindFinalTurnComplete, e.CompletedTurns)
+		w.putUvarint(len(e.Alive))
+		for _, cell := range e.Alive {
+			w.putCell(cell)
+		}
+	case gol.StateChange:
+		w.begin(kindStateChange, e.CompletedTurns)
+		w.putUvarint(int(e.NewState))
+	case gol.AliveCellsCount:
+		w.begin(kindAliveCellsCount, e.CompletedTurns)
+		w.putUvarint(e.CellsCount)
+	case gol.ImageOutputComplete:
+		w.begin(kindImageOutputComplete, e.CompletedTurns)
+		w.putUvarint(len(e.Filename))
+		w.w.WriteString(e.Filename)
+	case gol.CellsEdited:
+		w.begin(kindCellsEdited, e.CompletedTurns)
+	case gol.RateChanged:
+		w.begin(kindRateChanged, e.CompletedTurns)
+		w.putUvarint(e.TurnsPerSecond)
+	case gol.Stats:
+		w.begin(kindStats, e.CompletedTurns)
+		for _, value := range []int{e.Population, e.Births, e.Deaths, e.ChangedCells} {
+			w.putUvarint(value)
+		}
+		box := e.BoundingBox
+		for _, value := range []int{box.Min.X, box.Min.Y, box.Max.X, box.Max.Y} {
+			w.putVarint(value)
+		}
+		w.putUvarint(len(e.StripDensity))
+		var bits [8]byte
+		for _, density := range e.StripDensity {
+			binary.LittleEndian.PutUint64(bits[:], math.Float64bits(density))
+			w.w.Write(bits[:])
+		}
+	}
+	//bufio.Writer keeps the first error it meets, so checking once covers every write above
+	_, err := w.w.Write(nil)
+	return err
+}
+
+// Flush writes any buffered events out.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+//Starts an event, resetting the last flipped cell for anything but another flip
+func (w *Writer) begin(kind byte, turn int) {
+	w.w.WriteByte(kind)
+	w.putVarint(turn - w.turn)
+	w.turn = turn
+	if kind != kindCellFlipped {
+		w.last = -1
+	}
+}
+
+func (w *Writer) putCell(cell util.Cell) {
+	index := cell.Y*w.header.Width + cell.X
+	w.putVarint(index - w.last - 1)
+	w.last = index
+}
+
+func (w *Writer) putUvarint(value int) {
+	var buf [binary.MaxVarintLen64]byte
+	w.w.Write(buf[:binary.PutUvarint(buf[:], uint64(value))])
+}
+
+func (w *Writer) putVarint(value int) {
+	var buf [binary.MaxVarintLen64]byte
+	w.w.Write(buf[:binary.PutVarint(buf[:], int64(value))])
+}
+
+// Reader reads events back from a log.
+type Reader struct {
+	Header
+	r    *bufio.Reader
+	turn int
+	last int
+	//elapsed is how long into the recorded run the last TurnComplete read came
+	elapsed time.Duration
+}
+
+// NewReader reads the header from r and gives a Reader for the events that follow it.
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: bufio.NewReaderSize(r, 64*1024), last: -1}
+	start := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(reader.r, start); err != nil || string(start[:len(magic)]) != magic {
+		return nil, errors.New("not an event log")
+	}
+	if start[len(magic)] != version {
+		return nil, fmt.Errorf("event log version %v, expected %v", start[len(magic)], version)
+	}
+	for _, value := range []*int{&reader.Width, &reader.Height, &reader.Threads, &reader.Turns} {
+		var err error
+		if *value, err = reader.uvarint(); err != nil {
+			return nil, err
+		}
+	}
+	return reader, nil
+}
+
+// ReadHeader reads just the header of the log at path.
+func ReadHeader(path string) (Header, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Header{}, err
+	}
+	defer file.Close()
+	reader, err := NewReader(file)
+	if err != nil {
+		return Header{}, fmt.Errorf("%v: %v", path, err)
+	}
+	return reader.Header, nil
+}
+
+// Next gives the next event in the log, or io.EOF after the last one.
+// A log cut short part of the way through an event, as when a run is killed, gives io.ErrUnexpectedEOF.
+func (r *Reader) Next() (gol.Event, error) {
+	kind, err := r.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	delta, err := r.varint()
+	if err != nil {
+		return nil, unexpected(err)
+	}
+	r.turn += delta
+	if kind != kindCellFlipped {
+		r.last = -1
+	}
+
+	var event gol.Event
+	switch kind {
+	case kindCellFlipped:
+		var cell util.Cell
+		cell, err = r.cell()
+		event = gol.CellFlipped{CompletedTurns: r.turn, Cell: cell}
+	case kindTurnComplete:
+		var micros int
+		micros, err = r.uvarint()
+		r.elapsed += time.Duration(micros) * time.Microsecond
+		event = gol.TurnComplete{CompletedTurns: r.turn}
+	case kindFinalTurnComplete:
+		var count int
+		count, err = r.uvarint()
+		alive := make([]util.Cell, count)
+		for i := 0; i < count && err == nil; i++ {
+			alive[i], err = r.cell()
+		}
+		event = gol.FinalTurnComplete{CompletedTurns: r.turn, Alive: alive}
+	case kindStateChange:
+		var state int
+		state, err = r.uvarint()
+		event = gol.StateChange{CompletedTurns: r.turn, NewState: gol.State(state)}
+	case kindAliveCellsCount:
+		var count int
+		count, err = r.uvarint()
+		event = gol.AliveCellsCount{CompletedTurns: r.turn, CellsCount: count}
+	case kindImageOutputComplete:
+		var length int
+		length, err = r.uvarint()
+		name := make([]byte, length)
+		if err == nil {
+			_, err = io.ReadFull(r.r, name)
+		}
+		event = gol.ImageOutputComplete{CompletedTurns: r.turn, Filename: string(name)}
+	case kindCellsEdited:
+		event = gol.CellsEdited{CompletedTurns: r.turn}
+	case kindRateChanged:
+		var rate int
+		rate, err = r.uvarint()
+		event = gol.RateChanged{CompletedTurns: r.turn, TurnsPerSecond: rate}
+	case kindStats:
+		event, err = r.stats()
+	default:
+		return nil, fmt.Errorf("unknown event kind %v in the event log", kind)
+	}
+	if err != nil {
+		return nil, unexpected(err)
+	}
+	return event, nil
+}
+
+// Elapsed gives how long into the recorded run the last TurnComplete read came.
+func (r *Reader) Elapsed() time.Duration {
+	return r.elapsed
+}
+
+func (r *Reader) stats() (gol.Event, error) {
+	stats := gol.Stats{CompletedTurns: r.turn}
+	var err error
+	for _, value := range []*int{&stats.Population, &stats.Births, &stats.Deaths, &stats.ChangedCells} {
+		if *value, err = r.uvarint(); err != nil {
+			return nil, err
+		}
+	}
+	box := &stats.BoundingBox
+	for _, value := range []*int{&box.Min.X, &box.Min.Y, &box.Max.X, &box.Max.Y} {
+		if *value, err = r.varint(); err != nil {
+			return nil, err
+		}
+	}
+	count, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	stats.StripDensity = make([]float64, count)
+	var bits [8]byte
+	for i := range stats.StripDensity {
+		if _, err := io.ReadFull(r.r, bits[:]); err != nil {
+			return nil, err
+		}
+		stats.StripDensity[i] = math.Float64frombits(binary.LittleEndian.Uint64(bits[:]))
+	}
+	return stats, nil
+}
+
+func (r *Reader) cell() (util.Cell, error) {
+	delta, err := r.varint()
+	if err != nil {
+		return util.Cell{}, err
+	}
+	r.last += delta + 1
+	if r.last < 0 || r.last >= r.Width*r.Height {
+		return util.Cell{}, errors.New("flipped cell outside the world in the event log")
+	}
+	return util.Cell{X: r.last % r.Width, Y: r.last / r.Width}, nil
+}
+
+func (r *Reader) uvarint() (int, error) {
+	value, err := binary.ReadUvarint(r.r)
+	return int(value), err
+}
+
+func (r *Reader) varint() (int, error) {
+	value, err := binary.ReadVarint(r.r)
+	return int(value), err
+}
+
+//Running out of log inside an event means the log was cut short, not that it ended
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
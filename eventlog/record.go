@@ -0,0 +1,35 @@
+package eventlog
+
+import (
+	"os"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/logging"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+var eventLog = logging.Component("eventlog")
+
+// Record passes every event from in through to out, writing each one to a log at path.
+// The log is flushed whenever the state changes, so a paused or quit run can be replayed straight away.
+func Record(path string, p gol.Params, in <-chan gol.Event, out chan<- gol.Event) {
+	defer close(out)
+	file, err := os.Create(path)
+	util.Check(err)
+	defer file.Close()
+	writer, err := NewWriter(file, Header{Width: p.ImageWidth, Height: p.ImageHeight, Threads: p.Threads, Turns: p.Turns})
+	util.Check(err)
+
+	turn := 0
+	for event := range in {
+		util.Check(writer.Write(event))
+		turn = event.GetCompletedTurns()
+		switch event.(type) {
+		case gol.StateChange, gol.FinalTurnComplete:
+			util.Check(writer.Flush())
+		}
+		out <- event
+	}
+	util.Check(writer.Flush())
+	eventLog.Info("Event log output done", "turn", turn, "file", path)
+}
@@ -0,0 +1,336 @@
+package eventlog
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// maxSpeed is the fastest pace + steps up to before going as fast as possible. One over it is the slowest.
+const maxSpeed = 64
+
+// replay is the state of a replay between turns.
+type replay struct {
+	path     string
+	file     *os.File
+	reader   *Reader
+	events   chan<- gol.Event
+	commands <-chan gol.Command
+	// pending holds the commands taken while sending events, to be carried out before the next turn.
+	// A nil command stands for the commands channel being closed.
+	pending []gol.Command
+	speed   float64
+	paused  bool
+	turn    int
+	// world holds which cells are alive after the turns sent so far, to answer RequestWorld and to seek from.
+	world []bool
+	// ahead is the turn read but not yet sent, and held the events read while seeking that are for after it.
+	// Both are read again before the rest of the log.
+	ahead []gol.Event
+	held  []gol.Event
+	// seeked is set when a Seek has moved the replay, so that the turn read before it is read again from the new place.
+	seeked bool
+}
+
+// Replay sends the events in the log at path on events, as the engine sent them, and closes events at the end.
+// Turns are sent at speed times the pace they were recorded at, pauses included, or as fast as possible when speed is
+// 0. The replay starts at turn from, which is reached without sending the turns before it.
+// Like the engine it takes commands: p or SetPaused pauses and resumes, n steps a turn while paused, + and - double
+// and halve the speed, and q stops the replay. Seek moves it forward or back by a number of turns, sending only the
+// cells that differ between the two turns. Commands are taken while events are being sent, so sending one never
+// blocks for long, and carried out between turns at any speed. RequestWorld is answered with the world the turns sent
+// so far make.
+func Replay(path string, speed float64, from int, events chan<- gol.Event, commands <-chan gol.Command) {
+	defer close(events)
+	r := &replay{path: path, events: events, commands: commands, speed: speed}
+	r.open()
+	defer func() { r.file.Close() }()
+	eventLog.Info("Replaying", "file", path, "speed", speed, "from", from)
+
+	if from > 0 {
+		r.seek(from)
+	}
+	last := time.Now()
+	previous := r.reader.Elapsed()
+	for {
+		batch, ended := r.readTurn()
+		gap := r.reader.Elapsed() - previous
+		previous = r.reader.Elapsed()
+		r.ahead = batch
+
+		//Carry out the commands sent since the last turn, then wait for this one to be due, taking commands in the
+		//meantime
+		stepping, quit := r.takePending()
+		for !quit && !stepping && !r.seeked &&
+			(len(r.pending) > 0 || r.paused || r.speed > 0 && time.Since(last) < r.scale(gap)) {
+			//Carrying out a command can send events, and the commands taken meanwhile mustn't wait for another turn
+			if len(r.pending) > 0 {
+				stepping, quit = r.takePending()
+				continue
+			}
+			var due <-chan time.Time
+			if !r.paused {
+				due = time.After(r.scale(gap) - time.Since(last))
+			}
+			select {
+			case <-due:
+			case command, ok := <-r.commands:
+				if !ok {
+					command = nil
+				}
+				stepping, quit = r.carryOut(command)
+			}
+		}
+		if quit {
+			r.send(gol.StateChange{CompletedTurns: r.turn, NewState: gol.Quitting})
+			return
+		}
+		if r.seeked {
+			//The turn read is from where the replay was, so the next one is read from where it is now
+			r.seeked = false
+			previous = r.reader.Elapsed()
+			last = time.Now()
+			continue
+		}
+
+		r.ahead = nil
+		for _, event := range batch {
+			r.follow(event)
+			r.send(event)
+		}
+		last = time.Now()
+		if ended {
+			return
+		}
+	}
+}
+
+//Sends an event, keeping any commands sent while it waits for later
+func (r *replay) send(event gol.Event) {
+	for {
+		select {
+		case r.events <- event:
+			return
+		case command, ok := <-r.commands:
+			if !ok {
+				r.commands = nil
+				command = nil
+			}
+			r.pending = append(r.pending, command)
+		}
+	}
+}
+
+//Carries out the commands kept while sending and any others waiting on the channel, reporting whether a turn should
+//be stepped and whether the replay should stop
+func (r *replay) takePending() (stepping, quit bool) {
+	for {
+		var command gol.Command
+		if len(r.pending) > 0 {
+			command, r.pending = r.pending[0], r.pending[1:]
+		} else {
+			var ok bool
+			select {
+			case command, ok = <-r.commands:
+				if !ok {
+					command = nil
+				}
+			default:
+				return stepping, quit
+			}
+		}
+		step, stop := r.carryOut(command)
+		stepping = stepping || step
+		if stop {
+			return stepping, true
+		}
+	}
+}
+
+//Carries out a command from the channel, where nil means it was closed, reporting whether a turn should be stepped
+//and whether the replay should stop
+func (r *replay) carryOut(command gol.Command) (stepping, quit bool) {
+	if command == nil {
+		//Nothing can resume the replay any more
+		r.commands = nil
+		r.paused = false
+		return false, false
+	}
+	switch r.handle(command) {
+	case 'n':
+		return true, false
+	case 'q':
+		return false, true
+	}
+	return false, false
+}
+
+//Carries out a command, giving back n when a turn should be stepped and q when the replay should stop
+func (r *replay) handle(command gol.Command) rune {
+	switch command := command.(type) {
+	case gol.SetPaused:
+		if bool(command) != r.paused {
+			r.togglePause()
+		}
+	case gol.KeyPress:
+		switch command {
+		case 'p':
+			r.togglePause()
+		case 'n':
+			if r.paused {
+				return 'n'
+			}
+		case '+', '-':
+			r.speed = changeSpeed(r.speed, command == '+')
+			eventLog.Info("Replay speed changed", "turn", r.turn, "speed", r.speed)
+		case 'q':
+			return 'q'
+		case 's':
+			eventLog.Warn("The world can't be saved during a replay", "turn", r.turn)
+		}
+	case gol.Seek:
+		to := r.turn + int(command)
+		if to < 0 {
+			to = 0
+		}
+		eventLog.Info("Seeking", "turn", r.turn, "to", to)
+		r.seek(to)
+		r.seeked = true
+	case gol.RequestWorld:
+		var alive []util.Cell
+		for index, isAlive := range r.world {
+			if isAlive {
+				alive = append(alive, util.Cell{X: index % r.reader.Width, Y: index / r.reader.Width})
+			}
+		}
+		r.send(gol.WorldState{CompletedTurns: r.turn, Alive: alive})
+	}
+	return 0
+}
+
+//Keeps the turn and the world up to date with an event read from the log
+func (r *replay) follow(event gol.Event) {
+	switch event := event.(type) {
+	case gol.CellFlipped:
+		index := event.Cell.Y*r.reader.Width + event.Cell.X
+		r.world[index] = !r.world[index]
+	case gol.TurnComplete:
+		r.turn = event.CompletedTurns
+	}
+}
+
+func (r *replay) togglePause() {
+	r.paused = !r.paused
+	state := gol.Executing
+	if r.paused {
+		state = gol.Paused
+	}
+	r.send(gol.StateChange{CompletedTurns: r.turn, NewState: state})
+}
+
+//Gives how long to wait between turns that were gap apart in the recording
+func (r *replay) scale(gap time.Duration) time.Duration {
+	if r.speed <= 0 {
+		return 0
+	}
+	return time.Duration(float64(gap) / r.speed)
+}
+
+//Doubles or halves the speed, going from the fastest to as fast as possible and back
+func changeSpeed(speed float64, faster bool) float64 {
+	switch {
+	case faster && (speed == 0 || speed*2 > maxSpeed):
+		return 0
+	case faster:
+		return speed * 2
+	case speed == 0:
+		return maxSpeed
+	case speed/2 < 1.0/maxSpeed:
+		return speed
+	default:
+		return speed / 2
+	}
+}
+
+//Reads the events up to and including the next TurnComplete, reporting whether the log has ended
+func (r *replay) readTurn() ([]gol.Event, bool) {
+	var batch []gol.Event
+	for {
+		event, err := r.next()
+		if err != nil {
+			if err != io.EOF {
+				eventLog.Warn("The event log can't be read any further", "turn", r.turn, "error", err)
+			}
+			return batch, true
+		}
+		batch = append(batch, event)
+		if _, ok := event.(gol.TurnComplete); ok {
+			return batch, false
+		}
+	}
+}
+
+//Opens the log from the start, with no cells alive
+func (r *replay) open() {
+	if r.file != nil {
+		r.file.Close()
+	}
+	file, err := os.Open(r.path)
+	util.Check(err)
+	reader, err := NewReader(file)
+	util.Check(err)
+	r.file, r.reader = file, reader
+	r.world = make([]bool, reader.Width*reader.Height)
+	r.turn = 0
+	r.ahead, r.held = nil, nil
+}
+
+//Gives the next event, taking those read ahead first
+func (r *replay) next() (gol.Event, error) {
+	if len(r.ahead) > 0 {
+		event := r.ahead[0]
+		r.ahead = r.ahead[1:]
+		return event, nil
+	}
+	if len(r.held) > 0 {
+		event := r.held[0]
+		r.held = r.held[1:]
+		return event, nil
+	}
+	return r.reader.Next()
+}
+
+//Reads through the log to the end of turn to, going back to the start first if it is behind the replay, then sends
+//the cells that differ from the world shown before and a TurnComplete. The other events on the way are left out,
+//and the end of the log is kept to be sent as it is reached.
+func (r *replay) seek(to int) {
+	shown := append([]bool(nil), r.world...)
+	if to < r.turn {
+		r.open()
+	}
+	for {
+		event, err := r.next()
+		if err != nil {
+			if r.turn < to {
+				eventLog.Warn("The event log ends before the turn to seek to", "turn", r.turn, "to", to)
+			}
+			break
+		}
+		_, final := event.(gol.FinalTurnComplete)
+		if final || event.GetCompletedTurns() > to {
+			r.held = append(r.held, event)
+			break
+		}
+		r.follow(event)
+	}
+	for index, alive := range r.world {
+		if alive != shown[index] {
+			cell := util.Cell{X: index % r.reader.Width, Y: index / r.reader.Width}
+			r.send(gol.CellFlipped{CompletedTurns: r.turn, Cell: cell})
+		}
+	}
+	r.send(gol.TurnComplete{CompletedTurns: r.turn})
+}
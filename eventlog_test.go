@@ -0,0 +1,234 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/eventlog"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestEventLog records the 16x16 image for 100 turns with stats, checks that replaying the log as fast as possible
+// gives back exactly the events of the run, then that a replay from turn 50 starts with the world at turn 50, that q
+// stops a replay at any speed, that p pauses one going as fast as possible and that a paused replay seeks forward
+// and back to the worlds of the run.
+func TestEventLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventlog")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "run.gollog")
+
+	p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 16, ImageHeight: 16, ReportStats: true}
+	engineEvents := make(chan gol.Event, 1000)
+	recorded := make(chan gol.Event, 1000)
+	go eventlog.Record(path, p, engineEvents, recorded)
+	go gol.Run(p, engineEvents, nil)
+	var run []gol.Event
+	for event := range recorded {
+		run = append(run, event)
+	}
+
+	header, err := eventlog.ReadHeader(path)
+	util.Check(err)
+	if header != (eventlog.Header{Width: 16, Height: 16, Threads: 4, Turns: 100}) {
+		t.Errorf("Expected the header to describe the run, got %+v", header)
+	}
+	replayed := replayAll(path, 0, 0, nil)
+	if !reflect.DeepEqual(run, replayed) {
+		t.Fatalf("Expected the replay to give the %v events of the run, got %v", len(run), len(replayed))
+	}
+
+	world := make(map[util.Cell]bool)
+	turn50 := make(map[util.Cell]bool)
+	for _, event := range run {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			world[e.Cell] = !world[e.Cell]
+		case gol.TurnComplete:
+			if e.CompletedTurns == 50 {
+				for cell, alive := range world {
+					if alive {
+						turn50[cell] = true
+					}
+				}
+			}
+		}
+	}
+	fromTurn50 := replayAll(path, 0, 50, nil)
+	seeked := make(map[util.Cell]bool)
+	for i, event := range fromTurn50 {
+		if turnComplete, ok := event.(gol.TurnComplete); ok {
+			if turnComplete.CompletedTurns != 50 {
+				t.Errorf("Expected the first turn of the replay to be 50, got %v", turnComplete.CompletedTurns)
+			}
+			break
+		}
+		flipped, ok := event.(gol.CellFlipped)
+		if !ok || flipped.CompletedTurns != 50 {
+			t.Fatalf("Expected the replay to start with the cells alive at turn 50, got %#v at %v", event, i)
+		}
+		seeked[flipped.Cell] = true
+	}
+	if !reflect.DeepEqual(seeked, turn50) {
+		t.Errorf("Expected the %v cells alive at turn 50, got %v", len(turn50), len(seeked))
+	}
+	var final gol.FinalTurnComplete
+	for _, event := range fromTurn50 {
+		if e, ok := event.(gol.FinalTurnComplete); ok {
+			final = e
+		}
+	}
+	if final.CompletedTurns != 100 || len(final.Alive) != len(readAliveCells("check/images/16x16x100.pgm", 16, 16)) {
+		t.Errorf("Expected the replay from turn 50 to reach the final turn, got %#v", final)
+	}
+
+	for _, speed := range []float64{1, 0} {
+		commands := make(chan gol.Command, 1)
+		commands <- gol.KeyPress('q')
+		quit := replayAll(path, speed, 0, commands)
+		last, ok := quit[len(quit)-1].(gol.StateChange)
+		if !ok || last.NewState != gol.Quitting || len(quit) >= len(run) {
+			t.Errorf("Expected q to quit the replay at speed %v before the end, got %#v after %v events", speed,
+				quit[len(quit)-1], len(quit))
+		}
+	}
+
+	//A replay as fast as possible still pauses, and takes commands without blocking while it is sending
+	commands := make(chan gol.Command)
+	events := make(chan gol.Event)
+	go eventlog.Replay(path, 0, 0, events, commands)
+	commands <- gol.KeyPress('p')
+	for event := range events {
+		if e, ok := event.(gol.StateChange); ok && e.NewState == gol.Paused {
+			break
+		}
+	}
+	for i := 0; i < 10; i++ {
+		commands <- gol.KeyPress('+')
+	}
+	//Commands sent while the paused replay is sending are carried out without waiting for a turn
+	commands <- gol.KeyPress('p')
+	commands <- gol.KeyPress('p')
+	for event := range events {
+		if e, ok := event.(gol.StateChange); ok && e.NewState == gol.Paused {
+			break
+		}
+		if _, ok := event.(gol.TurnComplete); ok {
+			t.Fatal("Expected the replay to pause again before sending another turn")
+		}
+	}
+	select {
+	case event := <-events:
+		t.Errorf("Expected the paused replay to send nothing, got %#v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+	commands <- gol.KeyPress('q')
+	var stopped gol.Event
+	for event := range events {
+		stopped = event
+	}
+	if e, ok := stopped.(gol.StateChange); !ok || e.NewState != gol.Quitting {
+		t.Errorf("Expected q to quit the paused replay, got %#v", stopped)
+	}
+
+	//Seeks are taken while paused, from wherever the replay has got to, and stop at the start and the end of the log
+	worlds := worldsByTurn(run)
+	commands = make(chan gol.Command)
+	events = make(chan gol.Event)
+	go eventlog.Replay(path, 0, 40, events, commands)
+	shown := make(map[util.Cell]bool)
+	turn := -1
+	//Reads the events up to the next TurnComplete, keeping track of the world they show
+	nextTurn := func() {
+		for event := range events {
+			switch e := event.(type) {
+			case gol.CellFlipped:
+				shown[e.Cell] = !shown[e.Cell]
+			case gol.TurnComplete:
+				turn = e.CompletedTurns
+				return
+			}
+		}
+		t.Fatal("Expected the replay to carry on")
+	}
+	nextTurn()
+	commands <- gol.SetPaused(true)
+	for event := range events {
+		if e, ok := event.(gol.StateChange); ok && e.NewState == gol.Paused {
+			break
+		}
+		if e, ok := event.(gol.CellFlipped); ok {
+			shown[e.Cell] = !shown[e.Cell]
+		}
+		if e, ok := event.(gol.TurnComplete); ok {
+			turn = e.CompletedTurns
+		}
+	}
+	for _, step := range []int{30, -50, -1000, 25, 1000} {
+		from := turn
+		commands <- gol.Seek(step)
+		nextTurn()
+		expected := from + step
+		if expected < 0 {
+			expected = 0
+		}
+		if expected > 100 {
+			expected = 100
+		}
+		if turn != expected {
+			t.Errorf("Expected seeking %v turns from turn %v to reach turn %v, got %v", step, from, expected, turn)
+		}
+		if !reflect.DeepEqual(alive(shown), worlds[turn]) {
+			t.Errorf("Expected seeking %v turns to show the %v cells alive at turn %v, got %v", step,
+				len(worlds[turn]), turn, len(alive(shown)))
+		}
+	}
+	commands <- gol.KeyPress('q')
+	for range events {
+	}
+}
+
+//Gives the cells alive after each turn of the events of a run, with those alive before the first as turn 0
+func worldsByTurn(run []gol.Event) map[int]map[util.Cell]bool {
+	worlds := make(map[int]map[util.Cell]bool)
+	world := make(map[util.Cell]bool)
+	for _, event := range run {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			if _, ok := worlds[0]; !ok && e.CompletedTurns > 0 {
+				worlds[0] = alive(world)
+			}
+			world[e.Cell] = !world[e.Cell]
+		case gol.TurnComplete:
+			worlds[e.CompletedTurns] = alive(world)
+		}
+	}
+	return worlds
+}
+
+//Gives the cells that are alive in a world kept as flipped cells
+func alive(world map[util.Cell]bool) map[util.Cell]bool {
+	cells := make(map[util.Cell]bool)
+	for cell, isAlive := range world {
+		if isAlive {
+			cells[cell] = true
+		}
+	}
+	return cells
+}
+
+//Replays the log and gives every event sent
+func replayAll(path string, speed float64, from int, commands <-chan gol.Command) []gol.Event {
+	events := make(chan gol.Event, 1000)
+	go eventlog.Replay(path, speed, from, events, commands)
+	var replayed []gol.Event
+	for event := range events {
+		replayed = append(replayed, event)
+	}
+	return replayed
+}
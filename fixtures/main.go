@@ -0,0 +1,221 @@
+// Command fixtures makes the reference data in check/ that the tests compare the engines against: the world after
+// each of a list of turns as a PGM in check/images, and the number of alive cells after every turn as a CSV in
+// check/alive. It works the turns out with the slow, obviously correct stepper in the reference package, so it can
+// make fixtures for any size, rule and topology.
+//
+// Files are named like the fixtures that were given to us, WxHxT.pgm and WxH.csv, with the rule and topology added
+// when they aren't B3/S23 on a torus, e.g. 40x24x100-B36S23.pgm and 40x24-plane.csv. Run it from the root of the
+// repository, e.g.
+//
+//	go run ./fixtures -w 40 -h 24 -turns 0,1,100 -alive 100 -rule B36/S23
+//
+// The fixtures it has made in check/ are made again, exactly, by the go:generate lines below, with
+// go generate ./fixtures. A fixture added to check/ should have its command added to them.
+package main
+
+//go:generate go run . -w 40 -h 24 -turns 0,1,100 -alive 100 -seed 1 -density 0.5 -out ../check
+//go:generate go run . -w 40 -h 24 -turns 0,1,100 -alive 100 -seed 1 -density 0.5 -rule B36/S23 -out ../check
+//go:generate go run . -w 17 -h 31 -turns 0,1,100 -alive 100 -seed 2 -density 0.35 -out ../check
+
+import (
+	"bufio"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/logging"
+	"uk.ac.bris.cs/gameoflife/reference"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+var fixturesLog = logging.Component("fixtures")
+
+func main() {
+	width := flag.Int(
+		"w",
+		16,
+		"Specify the width of the world. Defaults to 16.")
+
+	height := flag.Int(
+		"h",
+		16,
+		"Specify the height of the world. Defaults to 16.")
+
+	turns := flag.String(
+		"turns",
+		"0,1,100",
+		"Specify the turns to save the world after, comma separated. Defaults to 0,1,100.")
+
+	aliveTurns := flag.Int(
+		"alive",
+		10000,
+		"Specify how many turns to count the alive cells of, or 0 for none. Defaults to 10000.")
+
+	rule := flag.String(
+		"rule",
+		"",
+		"Specify the rule in B/S notation. Defaults to B3/S23.")
+
+	topologyName := flag.String(
+		"topology",
+		"torus",
+		"Specify how the edges of the world join up: torus, cylinder or plane. Defaults to torus.")
+
+	input := flag.String(
+		"input",
+		"",
+		"Specify a .pgm the size of the world, or a pattern file to centre in it, to start from. "+
+			"Defaults to images/WxH.pgm, or a random world if there isn't one.")
+
+	seed := flag.Int64(
+		"seed",
+		1,
+		"Specify the seed of the random world. Defaults to 1.")
+
+	density := flag.Float64(
+		"density",
+		0.5,
+		"Specify the fraction of cells alive in the random world. Defaults to 0.5.")
+
+	out := flag.String(
+		"out",
+		"check",
+		"Specify the directory to write images/ and alive/ in. Defaults to check.")
+
+	logFlags := logging.AddFlags()
+
+	flag.Parse()
+	logFlags.Setup("fixtures")
+
+	parsedRule, err := gol.ParseRule(*rule)
+	util.Check(err)
+	topology, err := reference.ParseTopology(*topologyName)
+	util.Check(err)
+	saveAt := make(map[int]bool)
+	last := *aliveTurns
+	for _, turn := range strings.Split(*turns, ",") {
+		number, err := strconv.Atoi(turn)
+		util.Check(err)
+		saveAt[number] = true
+		if number > last {
+			last = number
+		}
+	}
+
+	world := startingWorld(*input, *width, *height, *seed, *density)
+	name := fixtureName(*width, *height, parsedRule, topology)
+	util.Check(os.MkdirAll(filepath.Join(*out, "images"), os.ModePerm))
+	util.Check(os.MkdirAll(filepath.Join(*out, "alive"), os.ModePerm))
+
+	var counts *csv.Writer
+	if *aliveTurns > 0 {
+		path := filepath.Join(*out, "alive", name.alive)
+		file, err := os.Create(path)
+		util.Check(err)
+		defer file.Close()
+		counts = csv.NewWriter(file)
+		util.Check(counts.Write([]string{"completed_turns", "alive_cells"}))
+		defer fixturesLog.Info("Alive cells output done", "file", path, "turns", *aliveTurns)
+	}
+
+	for turn := 0; ; turn++ {
+		if saveAt[turn] {
+			path := filepath.Join(*out, "images", name.image(turn))
+			util.Check(writePGM(path, world))
+			fixturesLog.Info("Image output done", "file", path, "turn", turn, "alive", world.Alive())
+		}
+		if turn > 0 && turn <= *aliveTurns {
+			util.Check(counts.Write([]string{strconv.Itoa(turn), strconv.Itoa(world.Alive())}))
+		}
+		if turn == last {
+			break
+		}
+		world = reference.Step(world, parsedRule, topology)
+	}
+	if counts != nil {
+		counts.Flush()
+		util.Check(counts.Error())
+	}
+}
+
+// names are the file names of a set of fixtures.
+type names struct {
+	base, suffix string
+	alive        string
+}
+
+//Names the fixtures, adding the rule and topology when they aren't the ones the given fixtures use
+func fixtureName(width, height int, rule gol.Rule, topology reference.Topology) names {
+	n := names{base: fmt.Sprintf("%vx%v", width, height)}
+	if rule.String() != gol.ConwayRule {
+		n.suffix += "-" + strings.Replace(rule.String(), "/", "", 1)
+	}
+	if topology != reference.Torus {
+		n.suffix += "-" + string(topology)
+	}
+	n.alive = n.base + n.suffix + ".csv"
+	return n
+}
+
+func (n names) image(turn int) string {
+	return n.base + "x" + strconv.Itoa(turn) + n.suffix + ".pgm"
+}
+
+//Loads the world to start from, or makes a random one
+func startingWorld(input string, width, height int, seed int64, density float64) reference.World {
+	if input == "" {
+		image := fmt.Sprintf("images/%vx%v.pgm", width, height)
+		if _, err := os.Stat(image); err != nil {
+			fixturesLog.Info("Starting from a random world", "seed", seed, "density", density)
+			random := rand.New(rand.NewSource(seed))
+			world := reference.NewWorld(width, height)
+			for y := range world {
+				for x := range world[y] {
+					world[y][x] = random.Float64() < density
+				}
+			}
+			return world
+		}
+		input = image
+	}
+
+	format, err := util.DetectFormat(input)
+	util.Check(err)
+	if format != util.FormatPGM {
+		pattern, err := util.ReadPatternFile(input)
+		util.Check(err)
+		cells := reference.NewWorld(width, height).Bytes()
+		pattern.Place(cells, pattern.Centre(width, height))
+		return reference.FromBytes(cells)
+	}
+	file, err := os.Open(input)
+	util.Check(err)
+	defer file.Close()
+	header, cells, err := util.ReadPNM(file, util.DefaultThreshold)
+	util.Check(err)
+	if header.Width != width || header.Height != height {
+		panic(fmt.Sprintf("%v is %vx%v, not %vx%v", input, header.Width, header.Height, width, height))
+	}
+	return reference.FromBytes(cells)
+}
+
+//Writes the world as a binary PGM, laid out as the given fixtures are
+func writePGM(path string, world reference.World) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	_, _ = fmt.Fprintf(writer, "P5\n%v %v\n255\n", len(world[0]), len(world))
+	for _, row := range world.Bytes() {
+		_, _ = writer.Write(row)
+	}
+	return writer.Flush()
+}
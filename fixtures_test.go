@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/reference"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestReference checks that the reference stepper gives the worlds in the fixtures we were given, so that the
+// fixtures made with it can be trusted, then that a blinker on the top edge loses a cell off the edge unless the top
+// and bottom are joined.
+func TestReference(t *testing.T) {
+	rule, _ := gol.ParseRule("")
+	for _, size := range []int{16, 64} {
+		file, err := os.Open(fmt.Sprintf("check/images/%vx%vx0.pgm", size, size))
+		util.Check(err)
+		_, cells, err := util.ReadPNM(file, util.DefaultThreshold)
+		util.Check(file.Close())
+		util.Check(err)
+		world := reference.FromBytes(cells)
+		for turn := 1; turn <= 100; turn++ {
+			world = reference.Step(world, rule, reference.Torus)
+		}
+
+		var given []util.Cell
+		for y, row := range world {
+			for x, alive := range row {
+				if alive {
+					given = append(given, util.Cell{X: x, Y: y})
+				}
+			}
+		}
+		expected := readAliveCells(fmt.Sprintf("check/images/%vx%vx100.pgm", size, size), size, size)
+		assertEqualBoard(t, given, expected, gol.Params{ImageWidth: size, ImageHeight: size, Turns: 100})
+	}
+
+	expected := map[reference.Topology]int{reference.Torus: 3, reference.Cylinder: 2, reference.Plane: 2}
+	for topology, alive := range expected {
+		blinker := reference.NewWorld(5, 5)
+		blinker[0][1], blinker[0][2], blinker[0][3] = true, true, true
+		if next := reference.Step(blinker, rule, topology); next.Alive() != alive {
+			t.Errorf("Expected %v cells of the blinker on a %v, got %v", alive, topology, next.Alive())
+		}
+	}
+}
+
+// TestFixtures runs the fixtures made by the fixtures command, which cover non-square worlds and other rules,
+// on odd numbers of threads, checking the world after 1 and 100 turns and the alive cells after every turn.
+func TestFixtures(t *testing.T) {
+	fixtures := []struct {
+		width, height int
+		rule, suffix  string
+	}{
+		{40, 24, "", ""},
+		{40, 24, "B36/S23", "-B36S23"},
+		{17, 31, "", ""},
+	}
+	for _, fixture := range fixtures {
+		counts := readAliveCountsFile(fmt.Sprintf("check/alive/%vx%v%v.csv", fixture.width, fixture.height,
+			fixture.suffix))
+		for _, turns := range []int{1, 100} {
+			expected := readAliveCells(fmt.Sprintf("check/images/%vx%vx%v%v.pgm", fixture.width, fixture.height,
+				turns, fixture.suffix), fixture.width, fixture.height)
+			for _, threads := range []int{1, 3, 5, 7, 11} {
+				p := gol.Params{Turns: turns, Threads: threads, ImageWidth: fixture.width,
+					ImageHeight: fixture.height, Rule: fixture.rule,
+					InputFile: fmt.Sprintf("check/images/%vx%vx0%v.pgm", fixture.width, fixture.height, fixture.suffix)}
+				t.Run(fmt.Sprintf("%vx%vx%v%v-%v", p.ImageWidth, p.ImageHeight, turns, fixture.suffix, threads),
+					func(t *testing.T) {
+						events := make(chan gol.Event, 1000)
+						go gol.Run(p, events, nil)
+						var cells []util.Cell
+						alive := make(map[util.Cell]bool)
+						for event := range events {
+							switch e := event.(type) {
+							case gol.CellFlipped:
+								alive[e.Cell] = !alive[e.Cell]
+							case gol.TurnComplete:
+								population := 0
+								for _, isAlive := range alive {
+									if isAlive {
+										population++
+									}
+								}
+								if population != counts[e.CompletedTurns] {
+									t.Errorf("At turn %v expected %v alive cells, got %v", e.CompletedTurns,
+										counts[e.CompletedTurns], population)
+								}
+							case gol.FinalTurnComplete:
+								cells = e.Alive
+							}
+						}
+						assertEqualBoard(t, cells, expected, p)
+					})
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestFormats writes a glider in each pattern format, with and without a file extension, loads it into a
+// 16x16 world and checks the world after 4 turns, both in the final event and the file saved in that format.
+func TestFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "formats")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+
+	glider := util.Pattern{
+		Width:  3,
+		Height: 3,
+		Alive:  []util.Cell{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}},
+	}
+	expected := []util.Cell{{X: 2, Y: 1}, {X: 3, Y: 2}, {X: 1, Y: 3}, {X: 2, Y: 3}, {X: 3, Y: 3}}
+
+	for _, format := range []string{util.FormatRLE, util.FormatCells, util.FormatLife106} {
+		for _, extension := range []string{util.Extension(format), ""} {
+			input := filepath.Join(dir, "glider-"+format+extension)
+			file, err := os.Create(input)
+			util.Check(err)
+			util.Check(util.WritePattern(file, glider, format))
+			util.Check(file.Close())
+
+			p := gol.Params{
+				Turns:        4,
+				Threads:      2,
+				ImageWidth:   16,
+				ImageHeight:  16,
+				InputFile:    input,
+				InputOffset:  &util.Cell{},
+				OutputFormat: format,
+			}
+			t.Run(filepath.Base(input), func(t *testing.T) {
+				events := make(chan gol.Event)
+				go gol.Run(p, events, nil)
+				var cells []util.Cell
+				for event := range events {
+					switch e := event.(type) {
+					case gol.FinalTurnComplete:
+						cells = e.Alive
+					}
+				}
+				assertEqualBoard(t, cells, expected, p)
+
+				output := fmt.Sprintf("out/%vx%vx%v%v", p.ImageWidth, p.ImageHeight, p.Turns, util.Extension(format))
+				detected, err := util.DetectFormat(output)
+				util.Check(err)
+				if detected != format {
+					t.Errorf("Expected %v to be detected as %v, got %v", output, format, detected)
+				}
+				pattern, err := util.ReadPatternFile(output)
+				util.Check(err)
+				if format == util.FormatLife106 {
+					//Life 1.06 only records coordinates, so the pattern comes back moved to (0, 0)
+					placed := make([][]byte, p.ImageHeight)
+					for i := range placed {
+						placed[i] = make([]byte, p.ImageWidth)
+					}
+					pattern.Place(placed, util.Cell{X: 1, Y: 1})
+					pattern = util.PatternFromWorld(placed, "")
+				}
+				assertEqualBoard(t, pattern.Alive, expected, p)
+			})
+		}
+	}
+}
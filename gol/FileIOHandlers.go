@@ -3,7 +3,8 @@ package gol
 import "strconv"
 
 //Helper function of distributor. We use this to create an initial world map from a file name
-func writeFromFileIO(imageHeight, imageWidth int, c distributorChannels) [][]byte {
+//An empty inputFile reads the image shipped for the world's size.
+func writeFromFileIO(imageHeight, imageWidth int, inputFile string, c distributorChannels) [][]byte {
 
 	//We create the worlds
 	var world [][]byte = make([][]byte, imageHeight)
@@ -12,14 +13,16 @@ func writeFromFileIO(imageHeight, imageWidth int, c distributorChannels) [][]byt
 	}
 
 	//We set the command to input to be able to read from the file
-	c.ioFilename <- strconv.Itoa(imageWidth) + "x" + strconv.Itoa(imageHeight)
-	c.ioCommand <- ioInput
+	if inputFile != "" {
+		c.ioFilename <- inputFile
+	} else {
+		c.ioFilename <- strconv.Itoa(imageWidth) + "x" + strconv.Itoa(imageHeight)
+	}
+	c.ioCommand <- ioInputRows
 
 	for i := 0; i < imageHeight; i++ {
-		for j := 0; j < imageWidth; j++ {
-			//We populate the slice one input at a time.
-			world[i][j] = <-(c.ioInput)
-		}
+		//We populate the slice one row at a time.
+		world[i] = <-(c.ioInputRows)
 	}
 
 	return world
@@ -28,11 +31,14 @@ func writeFromFileIO(imageHeight, imageWidth int, c distributorChannels) [][]byt
 //Helper function of distributor. We use this to create a .pgm file from a given world map
 func writeToFileIO(world [][]byte, p Params, filename string,
 	c distributorChannels) {
-	c.ioCommand <- ioOutput
+	c.ioLock.Lock()
+	defer c.ioLock.Unlock()
+	c.ioCommand <- ioOutputRows
 	c.ioFilename <- filename
 	for i := 0; i < p.ImageHeight; i++ {
-		for j := 0; j < p.ImageWidth; j++ {
-			c.ioOutput <- world[i][j]
-		}
+		//The io goroutine keeps the rows until the file is written, so it gets a copy
+		row := make([]byte, p.ImageWidth)
+		copy(row, world[i])
+		c.ioOutputRows <- row
 	}
 }
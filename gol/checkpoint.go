@@ -0,0 +1,109 @@
+package gol
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// checkpointHeader is the first line of every checkpoint file.
+const checkpointHeader = "#gol-checkpoint 1"
+
+// Checkpoint is everything needed to carry on a run from the turn it was saved at.
+// It is saved as the header line, a line of JSON holding everything but the world, then the world as a P5 pgm.
+type Checkpoint struct {
+	// Turn is the number of turns completed when the checkpoint was saved.
+	Turn   int
+	Params Params
+	// Rule is the rule being run, in B/S notation.
+	Rule string
+	// Seed is the seed of any random numbers the run depends on. The engine itself is deterministic,
+	// so it is 0 unless a seeded start is added. A run carried on from a checkpoint saves the same seed.
+	Seed  int64
+	World [][]byte `json:"-"`
+}
+
+// WriteCheckpoint saves checkpoint to path.
+func WriteCheckpoint(path string, checkpoint Checkpoint) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	_, _ = writer.WriteString(checkpointHeader + "\n")
+	metadata, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	_, _ = writer.Write(metadata)
+	_, _ = writer.WriteString("\n")
+	if err := (pgmEncoder{}).encode(writer, checkpoint.World); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// ReadCheckpoint loads the checkpoint saved at path.
+func ReadCheckpoint(path string) (Checkpoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	checkpoint, err := readCheckpointMetadata(reader, path)
+	if err != nil {
+		return checkpoint, err
+	}
+	header, world, err := util.ReadPNM(reader, util.DefaultThreshold)
+	if err != nil {
+		return checkpoint, err
+	}
+	if header.Width != checkpoint.Params.ImageWidth || header.Height != checkpoint.Params.ImageHeight {
+		return checkpoint, fmt.Errorf("%v holds a %vx%v world but was saved from a %vx%v run", path,
+			header.Width, header.Height, checkpoint.Params.ImageWidth, checkpoint.Params.ImageHeight)
+	}
+	checkpoint.World = world
+	return checkpoint, nil
+}
+
+// readCheckpointHeader loads everything in the checkpoint at path but the world.
+func readCheckpointHeader(path string) (Checkpoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	defer file.Close()
+	return readCheckpointMetadata(bufio.NewReader(file), path)
+}
+
+//Reads the header and JSON lines of a checkpoint, leaving the reader at the start of the world
+func readCheckpointMetadata(reader *bufio.Reader, path string) (Checkpoint, error) {
+	var checkpoint Checkpoint
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return checkpoint, err
+	}
+	if strings.TrimSpace(line) != checkpointHeader {
+		return checkpoint, fmt.Errorf("%v is not a checkpoint", path)
+	}
+	line, err = reader.ReadString('\n')
+	if err != nil {
+		return checkpoint, fmt.Errorf("%v ends before the world: %v", path, err)
+	}
+	if err := json.Unmarshal([]byte(line), &checkpoint); err != nil {
+		return checkpoint, fmt.Errorf("%v has malformed metadata: %v", path, err)
+	}
+	return checkpoint, nil
+}
@@ -0,0 +1,59 @@
+package gol
+
+import "uk.ac.bris.cs/gameoflife/util"
+
+// Command is anything the user can ask of a running game through RunCommands: a KeyPress, an EditCells, a
+// SetPaused, a RequestWorld or a Seek.
+type Command interface {
+	isCommand()
+}
+
+// KeyPress is a Command carrying one of the keys the game responds to: p, s, q, n, + or -.
+type KeyPress rune
+
+// EditCells is a Command that sets Cells alive, or dead when Alive is false.
+// Edits are made between turns, so all the workers of a turn see the same world.
+type EditCells struct {
+	Cells []util.Cell
+	Alive bool
+}
+
+// SetPaused is a Command that pauses the game, or resumes it when false. Unlike pressing p, it does nothing if the
+// game is already in that state, so it is safe to send without knowing whether the game is paused.
+type SetPaused bool
+
+// RequestWorld is a Command asking for the alive cells, which are sent in a WorldState once the turn being worked
+// on is complete.
+type RequestWorld struct{}
+
+// Seek is a Command that moves a replay forward by that many turns, or back when negative.
+// A running game can't be moved, so the engine leaves it out.
+type Seek int
+
+// SeekStep is how many turns the [ and ] keys move a replay back and forward.
+const SeekStep = 10
+
+func (KeyPress) isCommand() {}
+
+func (EditCells) isCommand() {}
+
+func (SetPaused) isCommand() {}
+
+func (RequestWorld) isCommand() {}
+
+func (Seek) isCommand() {}
+
+//Helper function of Run. Turns each key press into a KeyPress command.
+func keyCommands(keyPresses <-chan rune) <-chan Command {
+	if keyPresses == nil {
+		return nil
+	}
+	commands := make(chan Command)
+	go func() {
+		for key := range keyPresses {
+			commands <- KeyPress(key)
+		}
+		close(commands)
+	}()
+	return commands
+}
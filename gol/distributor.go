@@ -1,11 +1,13 @@
 package gol
 
 import (
+	"context"
 	"math"
-	"os"
+	"runtime/trace"
 	"strconv"
 	"sync"
 	"time"
+	"uk.ac.bris.cs/gameoflife/logging"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
@@ -24,6 +26,14 @@ type distributorChannels struct {
 	ioFilename chan<- string
 	ioOutput   chan<- byte
 	ioInput    <-chan byte
+
+	ioOutputRows chan<- []byte
+	ioInputRows  <-chan []byte
+
+	//ioLock stops worlds being saved from more than one goroutine at once getting mixed up
+	ioLock *sync.Mutex
+
+	log *logging.Logger
 }
 
 //Helper function to distributor to find the number of alive cells adjacent to the tile
@@ -109,22 +119,35 @@ func createStrip(world [][]byte, stripSizeList []int, workerNumber, imageHeight,
 	return strip
 }
 
-func manager(imageHeight int, imageWidth int, inputWorld [][]byte, out chan<- [][]byte) {
-	gameSlice := worker(imageHeight, imageWidth, inputWorld)
+func manager(imageHeight int, imageWidth int, inputWorld [][]byte, rule Rule, out chan<- [][]byte) {
+	gameSlice := worker(imageHeight, imageWidth, inputWorld, rule)
 	out <- gameSlice
 }
 
 //Helper function of distributor
 //Creates a strip for the worker and then the worker will perform GoL algorithm on such strip
-func executeWorker(inputWorld [][]byte, workerChannelList []chan [][]byte, stripSizeList []int, imageWidth,
+//The time taken for each is put in times, and shows as a region of the turn's task in an execution trace.
+func executeWorker(ctx context.Context, inputWorld [][]byte, workerChannelList []chan [][]byte, stripSizeList []int,
+	imageWidth,
 	imageHeight,
 	threads,
-	workerNumber int, waitGroup *sync.WaitGroup) {
-	var strip = createStrip(inputWorld, stripSizeList,
-		workerNumber, imageHeight, threads)
+	workerNumber int, rule Rule, times workerTimes, waitGroup *sync.WaitGroup) {
+	trace.Log(ctx, "worker", strconv.Itoa(workerNumber))
+	var start = time.Now()
+	var strip [][]byte
+	trace.WithRegion(ctx, "strip", func() {
+		strip = createStrip(inputWorld, stripSizeList,
+			workerNumber, imageHeight, threads)
+	})
+	times.strip[workerNumber] = time.Since(start)
 	var workerStripSize = (stripSizeList[workerNumber]) + BUFFER
-	manager(workerStripSize, imageWidth, strip,
-		workerChannelList[workerNumber])
+	start = time.Now()
+	trace.WithRegion(ctx, "compute", func() {
+		manager(workerStripSize, imageWidth, strip, rule,
+			workerChannelList[workerNumber])
+	})
+	times.compute[workerNumber] = time.Since(start)
+	workerTimeMetric.With(strconv.Itoa(workerNumber)).Add(times.compute[workerNumber].Seconds())
 	defer (*waitGroup).Done()
 }
 
@@ -142,53 +165,196 @@ func getAliveCellsCount(inputWorld [][]byte) int {
 	return aliveCells
 }
 
-//Manages the key press interrupts
-func goPressTrack(inputWorld [][]byte, keyPresses <-chan rune, c distributorChannels, p Params, turn chan int,
-	aliveCellsTicker *time.Ticker, pauseChannel chan bool) {
-	var turns = 0
+//What the distributor reports to goPressTrack at the end of every turn, and again after each edit to the world
+type turnReport struct {
+	turn  int
+	world [][]byte
+}
+
+//Manages the key press interrupts and cell edits
+//The distributor waits on pauseChannel after every turn: true carries on, false stops the game.
+//While it waits it makes any edits sent on editChannel, then reports the edited world on turn.
+func goPressTrack(inputWorld [][]byte, startTurn int, commands <-chan Command, c distributorChannels, p Params,
+	turn chan turnReport, pauseChannel chan bool, editChannel chan []EditCells) {
+	var turns = startTurn
+	var world = inputWorld
 	var paused = false
+	var quitting = false
+	//Whether the distributor is waiting on pauseChannel
+	var waiting = false
+	//Edits made while the workers are busy, kept until the turn ends
+	var pending []EditCells
+	//The target speed in turns per second, zero for as fast as possible, and when the last turn was let through
+	var rate = 0
+	var lastRelease time.Time
+	//Fires when the next turn is due at the target speed, while the distributor waits for it
+	var release <-chan time.Time
+	//Turns asked for with n while paused that haven't been let through yet
+	var steps = 0
+	//Whether the world has been asked for with RequestWorld, to be sent once the turn is complete
+	var worldWanted = false
+
+	//Lets the distributor carry on with the next turn
+	carryOn := func() {
+		pauseChannel <- true
+		waiting = false
+		release = nil
+		lastRelease = time.Now()
+	}
+	//Lets the distributor carry on now, or once the next turn is due at the target speed
+	carryOnAtRate := func() {
+		if delay := util.RateDelay(lastRelease, rate, time.Now()); delay > 0 {
+			release = time.After(delay)
+		} else {
+			carryOn()
+		}
+	}
+
+	//Pauses the game, or resumes it if it is paused
+	togglePause := func() {
+		if paused {
+			c.events <- StateChange{turns, Executing}
+			paused = !paused
+			if waiting {
+				carryOnAtRate()
+			}
+		} else {
+			c.events <- StateChange{turns, Paused}
+			paused = !paused
+			release = nil
+		}
+	}
+
 	for {
 		select {
-		case key := <-keyPresses:
-			if key == 's' {
-				//When s is pressed, we need to generate a PGM file with the current state of the board
-
-				var filename = strconv.Itoa(p.ImageWidth) + "x" + strconv.Itoa(p.ImageHeight) + "x" + strconv.
-					Itoa(turns)
-				writeToFileIO(inputWorld, p, filename, c)
-			} else if key == 'p' {
-				//When p is pressed, pause the processing and print the current turn that is being processed
-				//If p is pressed again resume the processing
-				if paused {
-					c.events <- StateChange{turns, Executing}
-					paused = !paused
-					pauseChannel <- true
+		case command, ok := <-commands:
+			if !ok {
+				commands = nil
+				continue
+			}
+			switch command := command.(type) {
+			case EditCells:
+				pending = append(pending, command)
+				if waiting {
+					editChannel <- pending
+					pending = nil
+					waiting = false
+					release = nil
+				}
+			case SetPaused:
+				if bool(command) != paused {
+					togglePause()
+				}
+			case RequestWorld:
+				//The distributor has sent every event of the turn while it waits, so the world can go straight out
+				if waiting {
+					c.events <- WorldState{turns, calculateAliveCells(world)}
 				} else {
-					c.events <- StateChange{turns, Paused}
-					paused = !paused
+					worldWanted = true
 				}
-
-			} else if key == 'q' {
-				//When q is pressed, generate a PGM file with the current state of the board then terminate
-				handleGameShutDown(inputWorld, p, turns, c, aliveCellsTicker)
-				//Exit the program
-				os.Exit(0)
+			case KeyPress:
+				key := rune(command)
+				if key == 's' {
+					//When s is pressed, we need to generate a PGM file with the current state of the board
+
+					var filename = strconv.Itoa(p.ImageWidth) + "x" + strconv.Itoa(p.ImageHeight) + "x" + strconv.
+						Itoa(turns)
+					writeToFileIO(world, p, filename, c)
+					//Wait for the file to be written, so that whoever pressed s can be told it is ready
+					c.ioLock.Lock()
+					c.ioCommand <- ioCheckIdle
+					<-c.ioIdle
+					c.ioLock.Unlock()
+					c.events <- ImageOutputComplete{turns, filename}
+				} else if key == 'p' {
+					//When p is pressed, pause the processing and print the current turn that is being processed
+					//If p is pressed again resume the processing
+					togglePause()
+				} else if key == 'q' {
+					//When q is pressed, the distributor generates a PGM file with the current state of the board
+					//then terminates
+					quitting = true
+					if waiting {
+						pauseChannel <- false
+						return
+					}
+				} else if key == 'n' && paused {
+					//When n is pressed while paused, run exactly one more turn
+					if waiting {
+						carryOn()
+					} else {
+						steps++
+					}
+				} else if key == '+' || key == '-' {
+					//+ and - change the target speed, which applies from the next turn
+					rate = util.ChangeRate(rate, key == '+')
+					c.events <- RateChanged{turns, rate}
+					if release != nil {
+						carryOnAtRate()
+					}
+				}
+			}
+		//When turn is incremented, or the world edited, we're informed of the change
+		case report := <-turn:
+			turns = report.turn
+			world = report.world
+			waiting = true
+			if len(pending) > 0 {
+				//The edits are made before anything else, so that the world saved on quitting includes them
+				editChannel <- pending
+				pending = nil
+				waiting = false
+				continue
+			}
+			if worldWanted {
+				c.events <- WorldState{turns, calculateAliveCells(world)}
+				worldWanted = false
+			}
+			if quitting {
+				pauseChannel <- false
+				return
 			}
-		//When turn is incremented, we're informed of the change
-		case t := <-turn:
-			turns = t
 			if !paused {
-				pauseChannel <- true
+				carryOnAtRate()
+			} else if steps > 0 {
+				steps--
+				carryOn()
 			}
+		//When the next turn is due at the target speed
+		case <-release:
+			carryOn()
 		}
 	}
 }
 
-func aliveCellsReporter(turn, aliveCells *int, ticker *time.Ticker, c distributorChannels) {
+//Progress shared between the distributor and the alive cells reporter
+type progressStruct struct {
+	turn       int
+	aliveCells int
+	lock       sync.Mutex
+}
+
+//Locks the progress, records the latest turn and alive cells count, then Unlocks it
+func (progress *progressStruct) update(turn, aliveCells int) {
+	progress.lock.Lock()
+	progress.turn = turn
+	progress.aliveCells = aliveCells
+	progress.lock.Unlock()
+}
+
+//Locks the progress and returns a consistent turn and alive cells count pair
+func (progress *progressStruct) get() (int, int) {
+	progress.lock.Lock()
+	defer progress.lock.Unlock()
+	return progress.turn, progress.aliveCells
+}
+
+func aliveCellsReporter(progress *progressStruct, ticker *time.Ticker, c distributorChannels) {
 	for {
 		select {
 		case <-ticker.C:
-			c.events <- AliveCellsCount{*turn, *aliveCells}
+			turn, aliveCells := progress.get()
+			c.events <- AliveCellsCount{turn, aliveCells}
 		}
 	}
 }
@@ -236,7 +402,7 @@ func mergeWorkerStrips(newWorld [][]byte, workerChannelList []chan [][]byte, str
 
 //Helper function of distributor
 //Performs necessary logic to end the game neatly
-func handleGameShutDown(world [][]byte, p Params, turns int, c distributorChannels,
+func handleGameShutDown(world [][]byte, p Params, rule Rule, seed int64, turns int, quit bool, c distributorChannels,
 	ticker *time.Ticker) {
 	var filename = strconv.Itoa(p.ImageWidth) + "x" + strconv.Itoa(p.ImageHeight) + "x" + strconv.Itoa(turns)
 
@@ -246,42 +412,139 @@ func handleGameShutDown(world [][]byte, p Params, turns int, c distributorChanne
 	c.ioCommand <- ioCheckIdle
 	<-c.ioIdle
 
+	//A run stopped early can be carried on with -resume
+	if quit {
+		resumed := p
+		resumed.ResumeFile = ""
+		checkpointError := WriteCheckpoint("out/"+filename+".checkpoint",
+			Checkpoint{Turn: turns, Params: resumed, Rule: rule.String(), Seed: seed, World: world})
+		util.Check(checkpointError)
+		c.log.Info("Checkpoint output done", "file", "out/"+filename+".checkpoint")
+	}
+
 	c.events <- StateChange{turns, Quitting}
 	ticker.Stop()
 	//Close the channel to stop the SDL goroutine gracefully. Removing may cause deadlock.
 	close(c.events)
 }
 
+//Helper function of distributor
+//Waits at the end of a turn until goPressTrack lets the game carry on, making any edits it sends in the meantime.
+//Returns the world to carry on from and whether the game should stop.
+func awaitNextTurn(world [][]byte, turn int, progress *progressStruct, c distributorChannels,
+	turnChannel chan<- turnReport, pauseChannel <-chan bool, editChannel <-chan []EditCells) ([][]byte, bool) {
+	for {
+		select {
+		case carryOn := <-pauseChannel:
+			return world, !carryOn
+		case edits := <-editChannel:
+			world = applyEdits(world, edits, turn, c)
+			progress.update(turn, getAliveCellsCount(world))
+			turnChannel <- turnReport{turn, world}
+		}
+	}
+}
+
+//Helper function of distributor
+//Returns a copy of the world with the edits made, sending CellFlipped for each cell that changes.
+//Only the rows that change are copied; the world itself is left alone as a snapshot of it may still be being written.
+func applyEdits(world [][]byte, edits []EditCells, turn int, c distributorChannels) [][]byte {
+	var edited = append([][]byte(nil), world...)
+	var copied = make([]bool, len(world))
+	for _, edit := range edits {
+		var value byte = DEAD
+		if edit.Alive {
+			value = LIVE
+		}
+		for _, cell := range edit.Cells {
+			if cell.Y < 0 || cell.Y >= len(edited) || cell.X < 0 || cell.X >= len(edited[cell.Y]) ||
+				edited[cell.Y][cell.X] == value {
+				continue
+			}
+			if !copied[cell.Y] {
+				edited[cell.Y] = append([]byte(nil), edited[cell.Y]...)
+				copied[cell.Y] = true
+			}
+			edited[cell.Y][cell.X] = value
+			c.events <- CellFlipped{CompletedTurns: turn, Cell: cell}
+		}
+	}
+	c.events <- CellsEdited{turn}
+	return edited
+}
+
 // distributor divides the work between workers and interacts with other goroutines.
-func distributor(p Params, c distributorChannels, keyPresses <-chan rune) {
+//progress is shared with RunCommands, which logs the turn from it.
+func distributor(p Params, c distributorChannels, commands <-chan Command, progress *progressStruct) {
 
 	var turn = 0
-	var aliveCells = 0
-	var inputWorld = writeFromFileIO(p.ImageHeight, p.ImageWidth, c)
+	rule, ruleError := ParseRule(p.Rule)
+	util.Check(ruleError)
+	var inputWorld [][]byte
+	var seed int64
+	if p.ResumeFile != "" {
+		//Carry on from the turn the checkpoint was saved at
+		checkpoint, checkpointError := ReadCheckpoint(p.ResumeFile)
+		util.Check(checkpointError)
+		turn = checkpoint.Turn
+		seed = checkpoint.Seed
+		inputWorld = checkpoint.World
+	} else {
+		inputWorld = writeFromFileIO(p.ImageHeight, p.ImageWidth, p.InputFile, c)
+	}
 
 	//We need to find the strip sized passed to each worker
 	var stripSizeList = distributeSliceSizes(p)
 
-	aliveCells = getAliveCellsCount(inputWorld)
+	progress.update(turn, getAliveCellsCount(inputWorld))
 	//We create a ticker
 	aliveCellsTicker := time.NewTicker(2 * time.Second)
 
 	//We report the alive cells every two secs
-	go aliveCellsReporter(&turn, &aliveCells, aliveCellsTicker, c)
+	go aliveCellsReporter(progress, aliveCellsTicker, c)
 
-	var turnChannel = make(chan int)
+	var turnChannel = make(chan turnReport)
 	var pauseChannel = make(chan bool)
-	//Keep track of any key presses by the user
-	go goPressTrack(inputWorld, keyPresses, c, p, turnChannel, aliveCellsTicker, pauseChannel)
+	var editChannel = make(chan []EditCells)
+	//Keep track of any key presses and edits by the user
+	go goPressTrack(inputWorld, turn, commands, c, p, turnChannel, pauseChannel, editChannel)
 
 	//We flip the cells
 	flipWorldCellsInitial(inputWorld, p.ImageHeight, p.ImageWidth, turn, c)
 
-	//Run the GoL algorithm for specified number of turns
-	for i := 0; i < p.Turns; i++ {
+	//Save snapshots in the background as the game runs, if asked to
+	var snapshots chan snapshot
+	var snapshotDone = make(chan bool)
+	var policy = snapshotPolicy{every: p.SnapshotEvery, interval: p.SnapshotInterval, last: time.Now()}
+	if p.SnapshotEvery > 0 || p.SnapshotInterval > 0 {
+		snapshots = make(chan snapshot)
+		var toWrite = make(chan turnReport)
+		go snapshotQueue(snapshots, toWrite)
+		go snapshotWriter(p, toWrite, snapshotDone, c)
+	}
+
+	//Each turn is a task in an execution trace, with regions for its phases, and is timed if asked for
+	var runCtx, runTask = trace.NewTask(context.Background(), "run")
+	defer runTask.End()
+	var timings *timingCollector
+	if p.ReportTimings {
+		timings = newTimingCollector(p.Threads)
+	}
+
+	//Run the GoL algorithm for specified number of turns, or until q is pressed
+	var quit = false
+	for turn < p.Turns && !quit {
+		var turnStart = time.Now()
+		var turnCtx, turnTask = trace.NewTask(runCtx, "turn")
+		trace.Logf(turnCtx, "turn", "%d", turn+1)
+		var times = newWorkerTimes(p.Threads)
 		var newWorld [][]byte
 		if p.Threads == 1 {
-			newWorld = worker(p.ImageHeight, p.ImageWidth, inputWorld)
+			trace.WithRegion(turnCtx, "compute", func() {
+				newWorld = worker(p.ImageHeight, p.ImageWidth, inputWorld, rule)
+			})
+			times.compute[0] = time.Since(turnStart)
+			workerTimeMetric.With("0").Add(times.compute[0].Seconds())
 		} else {
 			//	We need to make a wait group and communication channels for each strip
 			var waitGroup sync.WaitGroup
@@ -294,25 +557,62 @@ func distributor(p Params, c distributorChannels, keyPresses <-chan rune) {
 			for j := 0; j < p.Threads; j++ {
 				waitGroup.Add(1)
 				//We execute the workers concurrently
-				go executeWorker(inputWorld, workerChannelList,
-					stripSizeList, p.ImageHeight, p.ImageWidth, p.Threads, j,
-					&waitGroup)
+				go executeWorker(turnCtx, inputWorld, workerChannelList,
+					stripSizeList, p.ImageWidth, p.ImageHeight, p.Threads, j,
+					rule, times, &waitGroup)
 			}
 			waitGroup.Wait()
 
-			newWorld = mergeWorkerStrips(newWorld, workerChannelList, stripSizeList)
+			var mergeStart = time.Now()
+			trace.WithRegion(turnCtx, "merge", func() {
+				newWorld = mergeWorkerStrips(newWorld, workerChannelList, stripSizeList)
+			})
+			if timings != nil {
+				timings.merge.add(time.Since(mergeStart))
+			}
 		}
-		aliveCells = getAliveCellsCount(newWorld)
 		turn++
-		turnChannel <- turn
+		var aliveCells = getAliveCellsCount(newWorld)
+		progress.update(turn, aliveCells)
+		turnsMetric.Inc()
+		turnRateMetric.Mark()
+		populationMetric.Set(float64(aliveCells))
+		eventDepthMetric.Set(float64(len(c.events)))
+
+		//The turn is shown before waiting, so that while paused the user sees and edits the world the workers will use
+		var eventsStart = time.Now()
+		trace.WithRegion(turnCtx, "events", func() {
+			if p.ReportStats {
+				c.events <- calculateStats(inputWorld, newWorld, turn, stripSizeList, p)
+			}
+			flipWorldCellsIteration(inputWorld, newWorld, turn, p.ImageHeight, p.ImageWidth, c)
+		})
+		turnTask.End()
+		if timings != nil {
+			timings.events.add(time.Since(eventsStart))
+			timings.turn.add(time.Since(turnStart))
+			timings.addWorkers(times, p.Threads > 1)
+		}
+		turnChannel <- turnReport{turn, newWorld}
 
 		//Update alive cells
-		<-pauseChannel
+		inputWorld, quit = awaitNextTurn(newWorld, turn, progress, c, turnChannel, pauseChannel, editChannel)
 
-		flipWorldCellsIteration(inputWorld, newWorld, turn, p.ImageHeight, p.ImageHeight, c)
-		inputWorld = newWorld
+		if snapshots != nil && policy.due(turn, time.Now()) {
+			snapshots <- snapshot{turnReport{turn, inputWorld}, !policy.counted(turn)}
+		}
 	}
 
-	c.events <- FinalTurnComplete{turn, calculateAliveCells(inputWorld)}
-	handleGameShutDown(inputWorld, p, p.Turns, c, aliveCellsTicker)
+	if snapshots != nil {
+		close(snapshots)
+		<-snapshotDone
+	}
+
+	if timings != nil {
+		c.events <- timings.report(turn)
+	}
+	if !quit {
+		c.events <- FinalTurnComplete{turn, calculateAliveCells(inputWorld)}
+	}
+	handleGameShutDown(inputWorld, p, rule, seed, turn, quit, c, aliveCellsTicker)
 }
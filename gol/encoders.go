@@ -0,0 +1,98 @@
+package gol
+
+import (
+	"image"
+	"image/png"
+	"io"
+	"strconv"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// imageEncoder writes a world to a file in one of the output formats.
+type imageEncoder interface {
+	// extension gives the file extension, including the dot, of the files the encoder writes.
+	extension() string
+	encode(w io.Writer, world [][]byte) error
+}
+
+// newImageEncoder picks the encoder for Params.OutputFormat.
+func newImageEncoder(p Params) imageEncoder {
+	switch p.OutputFormat {
+	case "", util.FormatPGM:
+		return pgmEncoder{}
+	case util.FormatPNG:
+		return pngEncoder{}
+	default:
+		rule, ruleError := ParseRule(p.Rule)
+		util.Check(ruleError)
+		return patternEncoder{format: p.OutputFormat, rule: rule.String()}
+	}
+}
+
+// pgmEncoder writes binary (P5) pgm files with one byte per cell, a row at a time.
+type pgmEncoder struct{}
+
+func (pgmEncoder) extension() string {
+	return ".pgm"
+}
+
+func (pgmEncoder) encode(w io.Writer, world [][]byte) error {
+	var height = len(world)
+	var width = 0
+	if height > 0 {
+		width = len(world[0])
+	}
+
+	_, _ = io.WriteString(w, "P5\n")
+	//_, _ = io.WriteString(w, "# PGM file writer by pnmmodules (https://github.com/owainkenwayucl/pnmmodules).\n")
+	_, _ = io.WriteString(w, strconv.Itoa(width))
+	_, _ = io.WriteString(w, " ")
+	_, _ = io.WriteString(w, strconv.Itoa(height))
+	_, _ = io.WriteString(w, "\n")
+	_, _ = io.WriteString(w, strconv.Itoa(255))
+	_, _ = io.WriteString(w, "\n")
+
+	for y := 0; y < height; y++ {
+		_, ioError := w.Write(world[y])
+		if ioError != nil {
+			return ioError
+		}
+	}
+	return nil
+}
+
+// pngEncoder writes greyscale png files with one pixel per cell.
+type pngEncoder struct{}
+
+func (pngEncoder) extension() string {
+	return ".png"
+}
+
+func (pngEncoder) encode(w io.Writer, world [][]byte) error {
+	var height = len(world)
+	var width = 0
+	if height > 0 {
+		width = len(world[0])
+	}
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		copy(img.Pix[y*img.Stride:], world[y])
+	}
+	return png.Encode(w, img)
+}
+
+// patternEncoder writes pattern files along with the rule being run.
+type patternEncoder struct {
+	format string
+	rule   string
+}
+
+func (encoder patternEncoder) extension() string {
+	return util.Extension(encoder.format)
+}
+
+func (encoder patternEncoder) encode(w io.Writer, world [][]byte) error {
+	return util.WritePattern(w, util.PatternFromWorld(world, encoder.rule), encoder.format)
+}
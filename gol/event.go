@@ -2,10 +2,16 @@ package gol
 
 import (
 	"fmt"
+	"time"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
 // Event represents any Game of Life event that needs to be communicated to the user.
+// A run that finishes its turns ends with FinalTurnComplete, the ImageOutputComplete of the final image and a
+// StateChange to Quitting, then the events channel is closed.
+// A run stopped with q ends the same way but without the FinalTurnComplete, saving a checkpoint to carry on from
+// with Params.ResumeFile before the StateChange. The program is not exited, so whatever reads the events can finish up
+// when the channel is closed.
 type Event interface {
 	// Stringer allows each event to be printed by the GUI
 	fmt.Stringer
@@ -52,6 +58,13 @@ type CellFlipped struct { // implements Event
 	Cell           util.Cell
 }
 
+// RateChanged is an Event notifying the user about the speed the game has been set to with + or -.
+// TurnsPerSecond is zero when the game runs as fast as it can.
+type RateChanged struct { // implements Event
+	CompletedTurns int
+	TurnsPerSecond int
+}
+
 // TurnComplete is an Event notifying the GUI about turn completion.
 // SDL will render a frame when this event is sent.
 // All CellFlipped events must be sent *before* TurnComplete.
@@ -59,9 +72,66 @@ type TurnComplete struct { // implements Event
 	CompletedTurns int
 }
 
+// CellsEdited is an Event notifying the GUI that the user's edits to the world have been made.
+// It follows the CellFlipped events of the edit, in place of a TurnComplete as no turn has passed.
+type CellsEdited struct { // implements Event
+	CompletedTurns int
+}
+
+// WorldState is an Event carrying every cell alive after CompletedTurns, sent between turns in answer to a
+// RequestWorld, so that a GUI that has fallen out of step can show the world exactly again.
+type WorldState struct { // implements Event
+	CompletedTurns int
+	Alive          []util.Cell
+}
+
+// Stats is an Event carrying population statistics for a single turn.
+// This Event is only sent when Params.ReportStats is set, once per turn before its TurnComplete.
+type Stats struct { // implements Event
+	CompletedTurns int
+	Population     int
+	Births         int
+	Deaths         int
+	ChangedCells   int
+	// BoundingBox is the smallest rectangle containing every alive cell.
+	BoundingBox BoundingBox
+	// StripDensity holds the fraction of alive cells in each worker's strip, top to bottom.
+	StripDensity []float64
+}
+
+// BoundingBox is an inclusive rectangle of cells. Max is smaller than Min when the box is empty.
+type BoundingBox struct {
+	Min, Max util.Cell
+}
+
+// TimingReport is an Event summarising how long the parts of each turn took, to find which worker or phase is slow.
+// This Event is only sent when Params.ReportTimings is set, once at the end of the run, before any FinalTurnComplete.
+type TimingReport struct { // implements Event
+	CompletedTurns int
+	// Turn is the time from the start of a turn to its TurnComplete, leaving out any time spent paused.
+	Turn Timing
+	// Strips and Compute are the times a worker took to make its strip and to work it out, over every worker.
+	// They are empty with one thread, which works on the whole world without strips.
+	Strips, Compute Timing
+	// Merge is the time taken to put the strips back together, and Events to send the turn's events.
+	Merge, Events Timing
+	// Workers holds the compute time of each worker, top to bottom.
+	Workers []Timing
+	// Imbalance is the slowest worker's compute time over the mean worker's, averaged over the turns.
+	// 1 means the work was perfectly balanced.
+	Imbalance float64
+}
+
+// Timing is the shortest, mean and longest of a set of times. Count is how many there were.
+type Timing struct {
+	Min, Mean, Max time.Duration
+	Count          int
+}
+
 // FinalTurnComplete is an Event notifying the testing framework about the new world state after execution finished.
 // The data included with this Event is used directly by the tests.
 // SDL closes the window when this Event is sent.
+// It is not sent when the game is stopped with q, as the turns asked for were never completed.
 type FinalTurnComplete struct {
 	CompletedTurns int
 	Alive          []util.Cell
@@ -98,6 +168,17 @@ func (event AliveCellsCount) GetCompletedTurns() int {
 	return event.CompletedTurns
 }
 
+func (event RateChanged) String() string {
+	if event.TurnsPerSecond == 0 {
+		return fmt.Sprintf("Running at full speed")
+	}
+	return fmt.Sprintf("Running at %v turns per second", event.TurnsPerSecond)
+}
+
+func (event RateChanged) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
 func (event ImageOutputComplete) String() string {
 	return fmt.Sprintf("File %v output complete", event.Filename)
 }
@@ -114,6 +195,22 @@ func (event CellFlipped) GetCompletedTurns() int {
 	return event.CompletedTurns
 }
 
+func (event CellsEdited) String() string {
+	return fmt.Sprintf("")
+}
+
+func (event CellsEdited) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event WorldState) String() string {
+	return fmt.Sprintf("")
+}
+
+func (event WorldState) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
 func (event TurnComplete) String() string {
 	return fmt.Sprintf("")
 }
@@ -122,6 +219,27 @@ func (event TurnComplete) GetCompletedTurns() int {
 	return event.CompletedTurns
 }
 
+func (event Stats) String() string {
+	return fmt.Sprintf("")
+}
+
+func (event Stats) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event TimingReport) String() string {
+	return fmt.Sprintf("Turns took %v on average", event.Turn.Mean)
+}
+
+func (event TimingReport) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+// Empty reports whether the box contains no cells.
+func (box BoundingBox) Empty() bool {
+	return box.Max.X < box.Min.X || box.Max.Y < box.Min.Y
+}
+
 func (event FinalTurnComplete) String() string {
 	return fmt.Sprintf("")
 }
@@ -3,22 +3,20 @@ package gol
 //This file is where we have the game of life algorithm
 
 //Helper function to worker
-func updateUpdatedWorldTile(inputWorldTile, updatedWorldTile byte, adjacentAliveCells int) byte {
+func updateUpdatedWorldTile(inputWorldTile, updatedWorldTile byte, adjacentAliveCells int, rule Rule) byte {
 	// if the element is dead, then run through those checks
 	if inputWorldTile == DEAD {
-		if adjacentAliveCells > 0 {
-		}
-		if adjacentAliveCells == 3 {
+		if rule.Birth[adjacentAliveCells] {
 			updatedWorldTile = LIVE
 		} else {
 			updatedWorldTile = DEAD
 		}
 
 	} else {
-		if adjacentAliveCells < 2 || adjacentAliveCells > 3 {
-			updatedWorldTile = DEAD
-		} else {
+		if rule.Survival[adjacentAliveCells] {
 			updatedWorldTile = LIVE
+		} else {
+			updatedWorldTile = DEAD
 		}
 
 	}
@@ -27,7 +25,7 @@ func updateUpdatedWorldTile(inputWorldTile, updatedWorldTile byte, adjacentAlive
 }
 
 //Perform the game of life algorithm
-func worker(imageHeight int, imageWidth int, inputWorld [][]byte) [][]byte {
+func worker(imageHeight int, imageWidth int, inputWorld [][]byte, rule Rule) [][]byte {
 
 	//Create the result world
 	updatedWorld := make([][]byte, imageHeight)
@@ -58,7 +56,7 @@ func worker(imageHeight int, imageWidth int, inputWorld [][]byte) [][]byte {
 					int(inputWorld[(i+1+imageHeight)%imageHeight][(j+1+imageWidth)%imageWidth])
 			adjacentAliveCells = adjacentAliveCells / LIVE
 
-			updatedWorld[i][j] = updateUpdatedWorldTile(tile, updatedWorld[i][j], adjacentAliveCells)
+			updatedWorld[i][j] = updateUpdatedWorldTile(tile, updatedWorld[i][j], adjacentAliveCells, rule)
 		}
 	}
 
@@ -1,23 +1,146 @@
 package gol
 
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/logging"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// defaultSize is the width and height of the world when neither the flags nor an input file give one.
+const defaultSize = 512
+
 // Params provides the details of how to run the Game of Life and which image to load.
 type Params struct {
-	Turns       int
-	Threads     int
+	Turns   int
+	Threads int
+	// ImageWidth and ImageHeight are the size of the world. Zero means the size of the input file.
+	// A world larger than the input file has the input placed in it at InputOffset.
 	ImageWidth  int
 	ImageHeight int
+	// ReportStats enables a Stats event after every turn.
+	ReportStats bool
+	// ReportTimings enables a TimingReport event at the end of the run.
+	ReportTimings bool
+	// Rule is the rule to run in B/S notation. Empty means Conway's B3/S23, or the rule named by an RLE input file.
+	Rule string
+	// InputFile is the .pgm, .rle, .cells or Life 1.06 file to load. Empty means images/WxH.pgm.
+	InputFile string
+	// InputOffset places the top left corner of the input in a larger world. Nil centres the input in the world.
+	InputOffset *util.Cell
+	// Stamps are patterns placed on the world once it has been loaded, in order.
+	// With no InputFile they are placed on an empty world rather than the image for the world's size.
+	Stamps []util.Stamp
+	// OutputFormat is the format of saved images, one of the util.Format constants. Empty means "pgm".
+	OutputFormat string
+	// ResumeFile is a checkpoint to carry on from, instead of loading InputFile and Stamps.
+	// Turns still counts from the start of the original run.
+	ResumeFile string
+	// SnapshotEvery saves the world every SnapshotEvery turns while the game runs. Zero turns it off.
+	SnapshotEvery int
+	// SnapshotInterval saves the world whenever SnapshotInterval has passed since the last snapshot. Zero turns it off.
+	SnapshotInterval time.Duration
+	// SnapshotKeep is how many of the most recent snapshots to keep. Zero keeps them all.
+	SnapshotKeep int
+	// Threshold is the fraction of a pgm's maxval at or above which a pixel is alive. Zero means util.DefaultThreshold.
+	Threshold float64
+}
+
+// ResolveParams fills in the parts of p that come from the input file, or the checkpoint being resumed: the size of
+// the world when ImageWidth or ImageHeight is zero, and the rule when Rule is empty and the file names one.
+// A checkpoint also gives Turns and Threads when they are zero.
+// Threads is then cut down to the height of the world, as each worker needs at least a row to work on.
+// Run resolves its Params itself, but anything sized from Params before then, like the SDL window, needs to call it first.
+func ResolveParams(p Params) Params {
+	p = resolveInput(p)
+	if p.Threads > p.ImageHeight {
+		p.Threads = p.ImageHeight
+	}
+	return p
+}
+
+//Helper function of ResolveParams
+//Fills in the size and rule from the input file or checkpoint
+func resolveInput(p Params) Params {
+	if p.ResumeFile != "" {
+		checkpoint, ioError := readCheckpointHeader(p.ResumeFile)
+		util.Check(ioError)
+		if p.ImageWidth == 0 {
+			p.ImageWidth = checkpoint.Params.ImageWidth
+		}
+		if p.ImageHeight == 0 {
+			p.ImageHeight = checkpoint.Params.ImageHeight
+		}
+		if p.ImageWidth != checkpoint.Params.ImageWidth || p.ImageHeight != checkpoint.Params.ImageHeight {
+			panic(fmt.Sprintf("%v is a %vx%v world, not %vx%v", p.ResumeFile, checkpoint.Params.ImageWidth,
+				checkpoint.Params.ImageHeight, p.ImageWidth, p.ImageHeight))
+		}
+		if p.Rule == "" {
+			p.Rule = checkpoint.Rule
+		}
+		if p.Turns == 0 {
+			p.Turns = checkpoint.Params.Turns
+		}
+		if p.Threads == 0 {
+			p.Threads = checkpoint.Params.Threads
+		}
+		return p
+	}
+
+	if p.InputFile == "" {
+		//Without an input file the size picks one of the images we ship with
+		if p.ImageWidth == 0 {
+			p.ImageWidth = defaultSize
+		}
+		if p.ImageHeight == 0 {
+			p.ImageHeight = defaultSize
+		}
+		return p
+	}
+
+	path, ioError := inputPath(p.InputFile)
+	util.Check(ioError)
+	width, height, ioError := util.FileDimensions(path)
+	util.Check(ioError)
+	if p.ImageWidth == 0 {
+		p.ImageWidth = width
+	}
+	if p.ImageHeight == 0 {
+		p.ImageHeight = height
+	}
+	if width > p.ImageWidth || height > p.ImageHeight {
+		panic(fmt.Sprintf("%v is %vx%v, which does not fit in a %vx%v world", p.InputFile, width, height,
+			p.ImageWidth, p.ImageHeight))
+	}
+
+	//A rule in the input file applies unless one was asked for explicitly
+	if p.Rule == "" && isPattern(path) {
+		p.Rule = readPattern(path).Rule
+	}
+	return p
 }
 
 // Run starts the processing of Game of Life. It should initialise channels and goroutines.
 func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
+	RunCommands(p, events, keyCommands(keyPresses))
+}
+
+// RunCommands is Run with a channel of Commands instead of key presses, so that cells can be edited as well.
+func RunCommands(p Params, events chan<- Event, commands <-chan Command) {
 
 	//	TODO: Put the missing channels in here.
 
+	p = ResolveParams(p)
+
 	ioCommand := make(chan ioCommand)
 	ioIdle := make(chan bool)
 	ioFilename := make(chan string, 1)
 	ioOutput := make(chan uint8)
 	ioInput := make(chan uint8)
+	ioOutputRows := make(chan []byte)
+	ioInputRows := make(chan []byte)
 
 	ioChannels := ioChannels{
 		command:  ioCommand,
@@ -25,8 +148,17 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
 		filename: ioFilename,
 		output:   ioOutput,
 		input:    ioInput,
+
+		outputRows: ioOutputRows,
+		inputRows:  ioInputRows,
 	}
-	go startIo(p, ioChannels)
+	//Everything the engine logs carries the turn it has got to
+	progress := new(progressStruct)
+	turn := logging.Lazy(func() interface{} {
+		turn, _ := progress.get()
+		return turn
+	})
+	go startIo(p, ioChannels, logging.Component("io").With("turn", turn))
 
 	distributorChannels := distributorChannels{
 		events:     events,
@@ -35,6 +167,13 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
 		ioFilename: ioFilename,
 		ioOutput:   ioOutput,
 		ioInput:    ioInput,
+
+		ioOutputRows: ioOutputRows,
+		ioInputRows:  ioInputRows,
+
+		ioLock: &sync.Mutex{},
+
+		log: logging.Component("distributor").With("turn", turn),
 	}
-	distributor(p, distributorChannels, keyPresses)
+	distributor(p, distributorChannels, commands, progress)
 }
@@ -1,11 +1,10 @@
 package gol
 
 import (
+	"bufio"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"strconv"
-	"strings"
+	"uk.ac.bris.cs/gameoflife/logging"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
@@ -16,12 +15,16 @@ type ioChannels struct {
 	filename <-chan string
 	output   <-chan uint8
 	input    chan<- uint8
+
+	outputRows <-chan []byte
+	inputRows  chan<- []byte
 }
 
 // ioState is the internal ioState of the io goroutine.
 type ioState struct {
 	params   Params
 	channels ioChannels
+	log      *logging.Logger
 }
 
 // ioCommand allows requesting behaviour from the io (pgm) goroutine.
@@ -32,32 +35,52 @@ type ioCommand uint8
 //		ioOutput 	= 0
 //		ioInput 	= 1
 //		ioCheckIdle = 2
+//		ioOutputRows = 3
+//		ioInputRows = 4
+//
+// ioOutput and ioInput move the world a byte at a time and are only kept for compatibility.
+// ioOutputRows and ioInputRows move a whole row per channel operation, which is much faster for large worlds.
 const (
 	ioOutput ioCommand = iota
 	ioInput
 	ioCheckIdle
+	ioOutputRows
+	ioInputRows
 )
 
-// writePgmImage receives an array of bytes and writes it to a pgm file.
-func (io *ioState) writePgmImage() {
+// writeImage receives the world with receive and writes it to a file in the output format.
+func (io *ioState) writeImage(receive func() [][]byte) {
 	_ = os.Mkdir("out", os.ModePerm)
 
 	// Request a filename from the distributor.
 	filename := <-io.channels.filename
 
-	file, ioError := os.Create("out/" + filename + ".pgm")
+	world := receive()
+
+	encoder := newImageEncoder(io.params)
+	file, ioError := os.Create("out/" + filename + encoder.extension())
 	util.Check(ioError)
 	defer file.Close()
 
-	_, _ = file.WriteString("P5\n")
-	//_, _ = file.WriteString("# PGM file writer by pnmmodules (https://github.com/owainkenwayucl/pnmmodules).\n")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageWidth))
-	_, _ = file.WriteString(" ")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageHeight))
-	_, _ = file.WriteString("\n")
-	_, _ = file.WriteString(strconv.Itoa(255))
-	_, _ = file.WriteString("\n")
+	writer := bufio.NewWriter(file)
+	ioError = encoder.encode(writer, world)
+	util.Check(ioError)
+
+	ioError = writer.Flush()
+	util.Check(ioError)
+
+	ioError = file.Sync()
+	util.Check(ioError)
 
+	info, ioError := file.Stat()
+	util.Check(ioError)
+	ioBytesMetric.With("written").Add(float64(info.Size()))
+
+	io.log.Info("File output done", "file", filename)
+}
+
+// receiveBytes receives the world from the distributor one byte at a time.
+func (io *ioState) receiveBytes() [][]byte {
 	world := make([][]byte, io.params.ImageHeight)
 	for i := range world {
 		world[i] = make([]byte, io.params.ImageWidth)
@@ -65,74 +88,165 @@ func (io *ioState) writePgmImage() {
 
 	for y := 0; y < io.params.ImageHeight; y++ {
 		for x := 0; x < io.params.ImageWidth; x++ {
-			val := <-io.channels.output
-			//if val != 0 {
-			//	fmt.Println(x, y)
-			//}
-			world[y][x] = val
+			world[y][x] = <-io.channels.output
 		}
 	}
+	return world
+}
 
-	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
-			_, ioError = file.Write([]byte{world[y][x]})
-			util.Check(ioError)
+// receiveRows receives the world from the distributor one row at a time.
+func (io *ioState) receiveRows() [][]byte {
+	world := make([][]byte, io.params.ImageHeight)
+	for y := range world {
+		world[y] = <-io.channels.outputRows
+	}
+	return world
+}
+
+// readImage opens a pattern file, stamps any other patterns on it and sends the world to the distributor with send.
+func (io *ioState) readImage(send func(world [][]byte)) {
+
+	// Request a filename from the distributor.
+	filename := <-io.channels.filename
+	io.log.Debug("Reading the input", "file", filename)
+
+	var world [][]byte
+	if io.params.InputFile == "" && len(io.params.Stamps) > 0 {
+		//Stamps without an input file start from an empty world
+		world = make([][]byte, io.params.ImageHeight)
+		for i := range world {
+			world[i] = make([]byte, io.params.ImageWidth)
+		}
+	} else {
+		path, ioError := inputPath(filename)
+		util.Check(ioError)
+		if isPattern(path) {
+			world = io.readPatternImage(path)
+		} else {
+			world = io.readPgmImage(path)
+		}
+		if info, statError := os.Stat(path); statError == nil {
+			ioBytesMetric.With("read").Add(float64(info.Size()))
 		}
 	}
 
-	ioError = file.Sync()
-	util.Check(ioError)
+	//Stamps go on top of the loaded world in the order they were given
+	for _, stamp := range io.params.Stamps {
+		util.Check(stamp.Apply(world))
+	}
+	send(world)
 
-	fmt.Println("File", filename, "output done!")
+	io.log.Info("File input done", "file", filename)
 }
 
-// readPgmImage opens a pgm file and sends its data as an array of bytes.
-func (io *ioState) readPgmImage() {
+// sendBytes sends the world to the distributor one byte at a time.
+func (io *ioState) sendBytes(world [][]byte) {
+	for _, row := range world {
+		for _, b := range row {
+			io.channels.input <- b
+		}
+	}
+}
 
-	// Request a filename from the distributor.
-	filename := <-io.channels.filename
-	fmt.Println(filename)
+// sendRows sends the world to the distributor one row at a time.
+// The rows are handed over rather than copied, so the io goroutine must not touch them afterwards.
+func (io *ioState) sendRows(world [][]byte) {
+	for _, row := range world {
+		io.channels.inputRows <- row
+	}
+}
+
+//Gives the path of the file to read for an input filename. A bare WxH name refers to one of the images we ship with,
+//any other name must be a file that exists.
+func inputPath(filename string) (string, error) {
+	_, statError := os.Stat(filename)
+	if statError == nil {
+		return filename, nil
+	}
+	var width, height int
+	if _, scanError := fmt.Sscanf(filename, "%dx%d", &width, &height); scanError == nil &&
+		fmt.Sprintf("%vx%v", width, height) == filename {
+		return "images/" + filename + ".pgm", nil
+	}
+	return "", statError
+}
 
-	data, ioError := ioutil.ReadFile("images/" + filename + ".pgm")
+// readPgmImage opens a pgm or pbm file and returns the world it holds, placed in a larger world if need be.
+func (io *ioState) readPgmImage(path string) [][]byte {
+	file, ioError := os.Open(path)
 	util.Check(ioError)
-	fmt.Println("File read")
-	fields := strings.Fields(string(data))
+	defer file.Close()
 
-	if fields[0] != "P5" {
-		panic("Not a pgm file")
+	threshold := io.params.Threshold
+	if threshold == 0 {
+		threshold = util.DefaultThreshold
 	}
+	header, image, ioError := util.ReadPNM(file, threshold)
+	util.Check(ioError)
+	io.log.Debug("File read", "path", path)
 
-	width, _ := strconv.Atoi(fields[1])
-	if width != io.params.ImageWidth {
+	if header.Width > io.params.ImageWidth {
 		panic("Incorrect width")
 	}
 
-	height, _ := strconv.Atoi(fields[2])
-	if height != io.params.ImageHeight {
+	if header.Height > io.params.ImageHeight {
 		panic("Incorrect height")
 	}
 
-	maxval, _ := strconv.Atoi(fields[3])
-	if maxval != 255 {
-		panic("Incorrect maxval/bit depth")
+	if header.Width == io.params.ImageWidth && header.Height == io.params.ImageHeight {
+		return image
+	}
+	return io.placeInWorld(util.PatternFromWorld(image, ""))
+}
+
+// readPatternImage places a pattern in an empty world and returns the world.
+func (io *ioState) readPatternImage(path string) [][]byte {
+	return io.placeInWorld(readPattern(path))
+}
+
+// placeInWorld places a pattern in an empty world at the input offset and returns the world.
+func (io *ioState) placeInWorld(pattern util.Pattern) [][]byte {
+	if pattern.Width > io.params.ImageWidth || pattern.Height > io.params.ImageHeight {
+		panic(fmt.Sprintf("Pattern of %vx%v does not fit in the world", pattern.Width, pattern.Height))
+	}
+
+	offset := pattern.Centre(io.params.ImageWidth, io.params.ImageHeight)
+	if io.params.InputOffset != nil {
+		offset = *io.params.InputOffset
+	}
+
+	world := make([][]byte, io.params.ImageHeight)
+	for i := range world {
+		world[i] = make([]byte, io.params.ImageWidth)
 	}
+	pattern.Place(world, offset)
 
-	image := []byte(fields[4])
+	return world
+}
 
-	for _, b := range image {
-		//fmt.Println("Put in")
-		io.channels.input <- b
-		//fmt.Println("Taken out")
+//Reports whether the file at path is a pattern to place in the world, rather than a whole PGM world
+func isPattern(path string) bool {
+	if path == "" {
+		return false
 	}
+	format, ioError := util.DetectFormat(path)
+	util.Check(ioError)
+	return format != util.FormatPGM
+}
 
-	fmt.Println("File", filename, "input done!")
+//Reads the pattern file at path, whatever its format
+func readPattern(path string) util.Pattern {
+	pattern, ioError := util.ReadPatternFile(path)
+	util.Check(ioError)
+	return pattern
 }
 
 // startIo should be the entrypoint of the io goroutine.
-func startIo(p Params, c ioChannels) {
+func startIo(p Params, c ioChannels, log *logging.Logger) {
 	io := ioState{
 		params:   p,
 		channels: c,
+		log:      log,
 	}
 
 	for {
@@ -141,10 +255,15 @@ func startIo(p Params, c ioChannels) {
 		case command := <-io.channels.command:
 			switch command {
 			case ioInput:
-				fmt.Println("Input triggered")
-				io.readPgmImage()
+				io.log.Debug("Input triggered")
+				io.readImage(io.sendBytes)
+			case ioInputRows:
+				io.log.Debug("Input triggered")
+				io.readImage(io.sendRows)
 			case ioOutput:
-				io.writePgmImage()
+				io.writeImage(io.receiveBytes)
+			case ioOutputRows:
+				io.writeImage(io.receiveRows)
 			case ioCheckIdle:
 				io.channels.idle <- true
 			}
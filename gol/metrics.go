@@ -0,0 +1,15 @@
+package gol
+
+import "uk.ac.bris.cs/gameoflife/metrics"
+
+//What the engine reports at /metrics, when the metrics are served
+var (
+	turnsMetric      = metrics.NewCounter("gol_turns_completed_total", "Turns completed.")
+	turnRateMetric   = metrics.NewMeter("gol_turns_per_second", "Turns completed per second.")
+	populationMetric = metrics.NewGauge("gol_population", "Alive cells after the last turn.")
+	workerTimeMetric = metrics.NewCounterVec("gol_worker_compute_seconds_total",
+		"Time each worker has spent computing its strip of the world.", "worker")
+	eventDepthMetric = metrics.NewGauge("gol_event_channel_depth",
+		"Events waiting to be taken from the events channel at the end of the last turn.")
+	ioBytesMetric = metrics.NewCounterVec("gol_io_bytes_total", "Bytes of images read and written.", "direction")
+)
@@ -0,0 +1,74 @@
+package gol
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConwayRule is the rule of Conway's Game of Life, used whenever Params.Rule is empty.
+const ConwayRule = "B3/S23"
+
+// Rule is a life-like rule: the neighbour counts at which a dead cell is born and an alive cell survives.
+type Rule struct {
+	Birth    [9]bool
+	Survival [9]bool
+}
+
+// ParseRule reads a rule in B/S notation ("B3/S23") or the older S/B notation ("23/3").
+// An empty string is Conway's Game of Life.
+func ParseRule(rule string) (Rule, error) {
+	var parsed Rule
+	if rule == "" {
+		rule = ConwayRule
+	}
+
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(rule)), "/")
+	if len(parts) != 2 {
+		return parsed, fmt.Errorf("rule %q is not of the form B3/S23", rule)
+	}
+	birth, survival := parts[0], parts[1]
+	if strings.HasPrefix(survival, "B") || strings.HasPrefix(birth, "S") {
+		birth, survival = survival, birth
+	}
+	if !strings.HasPrefix(birth, "B") && !strings.HasPrefix(survival, "S") {
+		//S/B notation gives the survival counts first
+		birth, survival = survival, birth
+	}
+
+	if err := parseNeighbourCounts(strings.TrimPrefix(birth, "B"), &parsed.Birth); err != nil {
+		return parsed, fmt.Errorf("rule %q: %v", rule, err)
+	}
+	if err := parseNeighbourCounts(strings.TrimPrefix(survival, "S"), &parsed.Survival); err != nil {
+		return parsed, fmt.Errorf("rule %q: %v", rule, err)
+	}
+	return parsed, nil
+}
+
+//Helper function of ParseRule. Marks every digit in counts as set.
+func parseNeighbourCounts(counts string, set *[9]bool) error {
+	for _, digit := range counts {
+		if digit < '0' || digit > '8' {
+			return fmt.Errorf("%q is not a neighbour count", digit)
+		}
+		set[digit-'0'] = true
+	}
+	return nil
+}
+
+// String gives the rule in B/S notation.
+func (rule Rule) String() string {
+	var builder strings.Builder
+	builder.WriteString("B")
+	for count, born := range rule.Birth {
+		if born {
+			builder.WriteByte(byte('0' + count))
+		}
+	}
+	builder.WriteString("/S")
+	for count, survives := range rule.Survival {
+		if survives {
+			builder.WriteByte(byte('0' + count))
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,89 @@
+package gol
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// snapshotPolicy decides which turns are saved as snapshots while the game runs.
+type snapshotPolicy struct {
+	every    int
+	interval time.Duration
+	last     time.Time
+}
+
+// snapshot is a world to save. timed is set when it is only due by the time since the last snapshot.
+type snapshot struct {
+	report turnReport
+	timed  bool
+}
+
+//Reports whether the world after turn should be saved, by turn count or by the time since the last snapshot
+func (policy *snapshotPolicy) due(turn int, now time.Time) bool {
+	if policy.counted(turn) {
+		policy.last = now
+		return true
+	}
+	if policy.interval > 0 && now.Sub(policy.last) >= policy.interval {
+		policy.last = now
+		return true
+	}
+	return false
+}
+
+//Reports whether the world after turn is due by turn count, which is never skipped
+func (policy *snapshotPolicy) counted(turn int) bool {
+	return policy.every > 0 && turn%policy.every == 0
+}
+
+//Helper function of distributor. Saves the worlds it is sent through the io goroutine as they arrive,
+//keeping only the last p.SnapshotKeep of them. It reports on done once snapshots is closed and the last is written.
+func snapshotWriter(p Params, snapshots <-chan turnReport, done chan<- bool, c distributorChannels) {
+	var extension = newImageEncoder(p).extension()
+	var written []string
+	for report := range snapshots {
+		var filename = "snapshot-" + strconv.Itoa(p.ImageWidth) + "x" + strconv.Itoa(p.ImageHeight) + "x" +
+			strconv.Itoa(report.turn)
+		writeToFileIO(report.world, p, filename, c)
+		written = append(written, "out/"+filename+extension)
+
+		//The io goroutine has finished with any older snapshot once it has taken all of this one
+		if p.SnapshotKeep > 0 && len(written) > p.SnapshotKeep {
+			if removeError := os.Remove(written[0]); removeError != nil {
+				c.log.Warn("Could not remove an old snapshot", "file", written[0], "error", removeError)
+			}
+			written = written[1:]
+		}
+	}
+	done <- true
+}
+
+//Helper function of distributor. Passes the worlds it is offered on to the snapshot writer, queueing them while the
+//writer is busy so that saving never holds up the workers. Every snapshot due by turn count is kept, so a time-lapse
+//has no gaps. One due only by time replaces another due by time still waiting behind it, as only the latest matters.
+//It closes out once offers is closed and the queue is empty.
+func snapshotQueue(offers <-chan snapshot, out chan<- turnReport) {
+	var queued []snapshot
+	for offers != nil || len(queued) > 0 {
+		var next chan<- turnReport
+		var head turnReport
+		if len(queued) > 0 {
+			next = out
+			head = queued[0].report
+		}
+		select {
+		case offer, ok := <-offers:
+			if !ok {
+				offers = nil
+			} else if last := len(queued) - 1; offer.timed && last >= 0 && queued[last].timed {
+				queued[last] = offer
+			} else {
+				queued = append(queued, offer)
+			}
+		case next <- head:
+			queued = queued[1:]
+		}
+	}
+	close(out)
+}
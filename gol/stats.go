@@ -0,0 +1,70 @@
+package gol
+
+import "uk.ac.bris.cs/gameoflife/util"
+
+//Helper function of distributor
+//Compares the worlds either side of a turn and summarises the change as a Stats event
+func calculateStats(oldWorld, newWorld [][]byte, turn int, stripSizeList []int, p Params) Stats {
+	stats := Stats{
+		CompletedTurns: turn,
+		BoundingBox: BoundingBox{
+			Min: util.Cell{X: p.ImageWidth, Y: p.ImageHeight},
+			Max: util.Cell{X: -1, Y: -1},
+		},
+		StripDensity: make([]float64, len(stripSizeList)),
+	}
+
+	//The strips are laid out top to bottom, so we walk them alongside the rows
+	var strip = 0
+	var stripEnd = stripSizeList[0]
+	var stripAlive = 0
+	for i := 0; i < p.ImageHeight; i++ {
+		for i >= stripEnd {
+			stats.StripDensity[strip] = density(stripAlive, stripSizeList[strip], p.ImageWidth)
+			strip++
+			stripEnd += stripSizeList[strip]
+			stripAlive = 0
+		}
+
+		for j := 0; j < p.ImageWidth; j++ {
+			if oldWorld[i][j] != newWorld[i][j] {
+				stats.ChangedCells++
+				if newWorld[i][j] == LIVE {
+					stats.Births++
+				} else {
+					stats.Deaths++
+				}
+			}
+			if newWorld[i][j] != LIVE {
+				continue
+			}
+
+			stats.Population++
+			stripAlive++
+			if j < stats.BoundingBox.Min.X {
+				stats.BoundingBox.Min.X = j
+			}
+			if j > stats.BoundingBox.Max.X {
+				stats.BoundingBox.Max.X = j
+			}
+			if i < stats.BoundingBox.Min.Y {
+				stats.BoundingBox.Min.Y = i
+			}
+			stats.BoundingBox.Max.Y = i
+		}
+	}
+	stats.StripDensity[strip] = density(stripAlive, stripSizeList[strip], p.ImageWidth)
+
+	if stats.Population == 0 {
+		stats.BoundingBox = BoundingBox{Max: util.Cell{X: -1, Y: -1}}
+	}
+	return stats
+}
+
+//Fraction of the cells in a strip that are alive
+func density(alive, stripSize, imageWidth int) float64 {
+	if stripSize*imageWidth == 0 {
+		return 0
+	}
+	return float64(alive) / float64(stripSize*imageWidth)
+}
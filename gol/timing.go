@@ -0,0 +1,88 @@
+package gol
+
+import "time"
+
+//How long each worker took over its strip in one turn. Each worker fills in its own place, so no locking is needed.
+type workerTimes struct {
+	strip, compute []time.Duration
+}
+
+func newWorkerTimes(threads int) workerTimes {
+	return workerTimes{strip: make([]time.Duration, threads), compute: make([]time.Duration, threads)}
+}
+
+//The running shortest, longest and total of a set of times
+type timingTotal struct {
+	min, max, sum time.Duration
+	count         int
+}
+
+func (total *timingTotal) add(d time.Duration) {
+	if total.count == 0 || d < total.min {
+		total.min = d
+	}
+	if d > total.max {
+		total.max = d
+	}
+	total.sum += d
+	total.count++
+}
+
+func (total timingTotal) timing() Timing {
+	if total.count == 0 {
+		return Timing{}
+	}
+	return Timing{Min: total.min, Mean: total.sum / time.Duration(total.count), Max: total.max, Count: total.count}
+}
+
+//Collects the times of every turn for the TimingReport at the end of the run
+type timingCollector struct {
+	turn, strips, compute, merge, events timingTotal
+	workers                              []timingTotal
+	//imbalance is the sum over turns of the slowest worker's time over the mean worker's
+	imbalance float64
+	turns     int
+}
+
+func newTimingCollector(threads int) *timingCollector {
+	return &timingCollector{workers: make([]timingTotal, threads)}
+}
+
+//Adds the times of a turn's workers. With one thread there is no strip to time.
+func (timings *timingCollector) addWorkers(times workerTimes, strips bool) {
+	var slowest, sum time.Duration
+	for worker, compute := range times.compute {
+		if strips {
+			timings.strips.add(times.strip[worker])
+		}
+		timings.compute.add(compute)
+		timings.workers[worker].add(compute)
+		sum += compute
+		if compute > slowest {
+			slowest = compute
+		}
+	}
+	if sum > 0 {
+		timings.imbalance += float64(slowest) * float64(len(times.compute)) / float64(sum)
+		timings.turns++
+	}
+}
+
+func (timings *timingCollector) report(turn int) TimingReport {
+	report := TimingReport{
+		CompletedTurns: turn,
+		Turn:           timings.turn.timing(),
+		Strips:         timings.strips.timing(),
+		Compute:        timings.compute.timing(),
+		Merge:          timings.merge.timing(),
+		Events:         timings.events.timing(),
+		Workers:        make([]Timing, len(timings.workers)),
+	}
+	for worker, total := range timings.workers {
+		report.Workers[worker] = total.timing()
+	}
+	if timings.turns > 0 {
+		report.Imbalance = timings.imbalance / float64(timings.turns)
+	}
+	return report
+}
@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"flag"
+	"os"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Flags are the command line flags that set up the default logger.
+type Flags struct {
+	level  *string
+	asJSON *bool
+}
+
+// AddFlags adds -log-level and -log-json to the command line flags. Setup must be called once they are parsed.
+func AddFlags() *Flags {
+	return &Flags{
+		level: flag.String(
+			"log-level",
+			"info",
+			"Specify the least important log messages to write: debug, info, warn or error. Defaults to info."),
+		asJSON: flag.Bool(
+			"log-json",
+			false,
+			"Write log messages as JSON lines instead of key=value text. Disabled by default."),
+	}
+}
+
+// Setup makes the default logger write to stderr as the flags ask, adding the node id and then the fields given to
+// every message.
+func (f *Flags) Setup(node string, fields ...interface{}) {
+	level, err := ParseLevel(*f.level)
+	util.Check(err)
+	SetDefault(New(os.Stderr, level, *f.asJSON).With(append([]interface{}{"node", node}, fields...)...))
+}
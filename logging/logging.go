@@ -0,0 +1,206 @@
+// Package logging writes levelled, structured log messages with key/value fields, as text or as JSON lines,
+// in the style of log/slog. Every binary sets up the default logger from its -log-level and -log-json flags.
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is how important a message is. Messages below the logger's level aren't written.
+type Level int
+
+// The levels, spaced out as slog's are.
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+// String gives the level's name as it is written in messages.
+func (level Level) String() string {
+	switch level {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return "LEVEL(" + strconv.Itoa(int(level)) + ")"
+	}
+}
+
+// ParseLevel reads a level given as debug, info, warn or error, in any case.
+func ParseLevel(text string) (Level, error) {
+	for _, level := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		if strings.EqualFold(text, level.String()) {
+			return level, nil
+		}
+	}
+	return 0, errors.New("unknown log level " + strconv.Quote(text) + ", expected debug, info, warn or error")
+}
+
+// Lazy is a field value that is only worked out when a message is written, such as the current turn.
+type Lazy func() interface{}
+
+// output is where a logger's messages go, shared by every logger made from it with With.
+type output struct {
+	lock   sync.Mutex
+	writer io.Writer
+	level  Level
+	json   bool
+}
+
+// Logger writes messages with its fields, followed by the fields given with each message.
+// Fields are given as alternating keys and values. A Logger can be used from many goroutines at once.
+type Logger struct {
+	//out is nil for a component logger, which writes to whatever the default logger is when the message is written
+	out    *output
+	fields []interface{}
+}
+
+var defaultLock sync.Mutex
+var defaultLogger = New(os.Stderr, LevelInfo, false)
+
+// New makes a Logger that writes messages at level or above to w, as JSON lines if asJSON is set.
+func New(w io.Writer, level Level, asJSON bool) *Logger {
+	return &Logger{out: &output{writer: w, level: level, json: asJSON}}
+}
+
+// Default gives the logger that component loggers write through.
+func Default() *Logger {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	return defaultLogger
+}
+
+// SetDefault replaces the default logger, so that every component logger writes through it from now on.
+func SetDefault(logger *Logger) {
+	defaultLock.Lock()
+	defaultLogger = logger
+	defaultLock.Unlock()
+}
+
+// Component gives a logger for a part of the program, which adds its name to every message as the component field.
+// It writes through the default logger as it is when each message is written, so packages can make theirs before
+// main has set the default up from the flags.
+func Component(name string) *Logger {
+	return &Logger{fields: []interface{}{"component", name}}
+}
+
+// With gives a logger that adds the fields to every message, after this logger's own.
+func (l *Logger) With(fields ...interface{}) *Logger {
+	return &Logger{out: l.out, fields: append(append([]interface{}(nil), l.fields...), fields...)}
+}
+
+//Gives the output to write to and all the fields to write, following a component logger through to the default
+func (l *Logger) resolve() (*output, []interface{}) {
+	if l.out != nil {
+		return l.out, l.fields
+	}
+	base := Default()
+	return base.out, append(append([]interface{}(nil), base.fields...), l.fields...)
+}
+
+// Enabled reports whether messages at level are written, so that costly fields can be skipped.
+func (l *Logger) Enabled(level Level) bool {
+	out, _ := l.resolve()
+	return level >= out.level
+}
+
+// Debug, Info, Warn and Error write a message at their level.
+func (l *Logger) Debug(msg string, fields ...interface{}) {
+	l.Log(LevelDebug, msg, fields...)
+}
+
+func (l *Logger) Info(msg string, fields ...interface{}) {
+	l.Log(LevelInfo, msg, fields...)
+}
+
+func (l *Logger) Warn(msg string, fields ...interface{}) {
+	l.Log(LevelWarn, msg, fields...)
+}
+
+func (l *Logger) Error(msg string, fields ...interface{}) {
+	l.Log(LevelError, msg, fields...)
+}
+
+// Log writes a message at level with the logger's fields and then the ones given.
+func (l *Logger) Log(level Level, msg string, fields ...interface{}) {
+	out, own := l.resolve()
+	if level < out.level {
+		return
+	}
+	all := append(append(make([]interface{}, 0, len(own)+len(fields)+1), own...), fields...)
+	if len(all)%2 == 1 {
+		all = append(all[:len(all)-1], "!BADKEY", all[len(all)-1])
+	}
+
+	var line strings.Builder
+	if out.json {
+		writeJSON(&line, time.Now(), level, msg, all)
+	} else {
+		writeText(&line, time.Now(), level, msg, all)
+	}
+	out.lock.Lock()
+	_, _ = io.WriteString(out.writer, line.String())
+	out.lock.Unlock()
+}
+
+//Gives the value to write for a field, working out Lazy values and using the message of errors
+func resolveValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case Lazy:
+		return resolveValue(v())
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return value
+}
+
+//Writes a message as key=value pairs, quoting any value with spaces, quotes or equals signs in it
+func writeText(line *strings.Builder, now time.Time, level Level, msg string, fields []interface{}) {
+	line.WriteString("time=" + now.Format(time.RFC3339Nano) + " level=" + level.String() + " msg=" + quote(msg))
+	for i := 0; i < len(fields); i += 2 {
+		line.WriteString(" " + fmt.Sprint(fields[i]) + "=" + quote(fmt.Sprint(resolveValue(fields[i+1]))))
+	}
+	line.WriteByte('\n')
+}
+
+func quote(text string) string {
+	if text == "" || strings.ContainsAny(text, " \t\n\"=") {
+		return strconv.Quote(text)
+	}
+	return text
+}
+
+//Writes a message as a JSON object on one line. A value that can't be encoded is written with fmt instead.
+func writeJSON(line *strings.Builder, now time.Time, level Level, msg string, fields []interface{}) {
+	line.WriteString(`{"time":` + encode(now.Format(time.RFC3339Nano)) + `,"level":` + encode(level.String()) +
+		`,"msg":` + encode(msg))
+	for i := 0; i < len(fields); i += 2 {
+		line.WriteString("," + encode(fmt.Sprint(fields[i])) + ":" + encode(resolveValue(fields[i+1])))
+	}
+	line.WriteString("}\n")
+}
+
+func encode(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return string(encoded)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/logging"
+)
+
+// TestLogging checks that messages below the level are left out and that text fields are quoted when they need to be,
+// then runs the 16x16 image for 10 turns logging JSON, checking that every message carries its component, node and
+// turn.
+func TestLogging(t *testing.T) {
+	var text bytes.Buffer
+	logger := logging.New(&text, logging.LevelWarn, false).With("node", "test")
+	logger.Info("Left out")
+	logger.Warn("Kept", "file", "a b.pgm", "error", errors.New("gone"), "rows", 16)
+	written := text.String()
+	if strings.Contains(written, "Left out") {
+		t.Errorf("Expected info messages to be left out at warn, got %q", written)
+	}
+	if !strings.Contains(written, ` level=WARN msg=Kept node=test file="a b.pgm" error=gone rows=16`+"\n") {
+		t.Errorf("Expected the warning with its fields, got %q", written)
+	}
+	if _, err := logging.ParseLevel("loud"); err == nil {
+		t.Error("Expected an unknown level to be an error")
+	}
+
+	var lines bytes.Buffer
+	before := logging.Default()
+	logging.SetDefault(logging.New(&lines, logging.LevelDebug, true).With("node", "local"))
+	defer logging.SetDefault(before)
+
+	p := gol.Params{Turns: 10, Threads: 4, ImageWidth: 16, ImageHeight: 16}
+	events := make(chan gol.Event, 1000)
+	go gol.Run(p, events, nil)
+	for range events {
+	}
+
+	var saved bool
+	for _, line := range strings.Split(strings.TrimSpace(lines.String()), "\n") {
+		var message map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &message); err != nil {
+			t.Fatalf("Expected a JSON line, got %q", line)
+		}
+		for _, key := range []string{"time", "level", "msg", "component", "node", "turn"} {
+			if _, ok := message[key]; !ok {
+				t.Errorf("Expected %v in %q", key, line)
+			}
+		}
+		if message["msg"] == "File output done" {
+			saved = true
+			if message["component"] != "io" || message["turn"] != 10.0 || message["file"] != "16x16x10" {
+				t.Errorf("Expected the io component to save 16x16x10 at turn 10, got %q", line)
+			}
+		}
+	}
+	if !saved {
+		t.Errorf("Expected the final world to be logged as saved, got %q", lines.String())
+	}
+}
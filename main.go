@@ -5,10 +5,20 @@ import (
 	"fmt"
 	"runtime"
 
+	"uk.ac.bris.cs/gameoflife/eventlog"
 	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/logging"
+	"uk.ac.bris.cs/gameoflife/metrics"
 	"uk.ac.bris.cs/gameoflife/sdl"
+	"uk.ac.bris.cs/gameoflife/term"
+	"uk.ac.bris.cs/gameoflife/util"
+	"uk.ac.bris.cs/gameoflife/web"
 )
 
+// mainLog and recordLog are for messages from main and from the recording.
+var mainLog = logging.Component("main")
+var recordLog = logging.Component("record")
+
 // main is the function called when starting Game of Life with 'go run .'
 func main() {
 	runtime.LockOSThread()
@@ -23,14 +33,14 @@ func main() {
 	flag.IntVar(
 		&params.ImageWidth,
 		"w",
-		512,
-		"Specify the width of the image. Defaults to 512.")
+		0,
+		"Specify the width of the image. Defaults to the input file's, or 512 without one.")
 
 	flag.IntVar(
 		&params.ImageHeight,
 		"h",
-		512,
-		"Specify the height of the image. Defaults to 512.")
+		0,
+		"Specify the height of the image. Defaults to the input file's, or 512 without one.")
 
 	flag.IntVar(
 		&params.Turns,
@@ -43,22 +53,287 @@ func main() {
 		false,
 		"Disables the SDL window, so there is no visualisation during the tests.")
 
+	terminal := flag.String(
+		"term",
+		"",
+		"Draw the world in the terminal instead of an SDL window, with half or braille characters. Disabled by default.")
+
+	httpAddr := flag.String(
+		"http",
+		"",
+		"Serve a live view of the game to browsers, and an API to control it, at the given address, e.g. :8080. "+
+			"Disabled by default.")
+
+	metricsAddr := flag.String(
+		"metrics",
+		"",
+		"Serve metrics in the Prometheus text format at /metrics on the given address, e.g. :9100. "+
+			"They are also served on the -http address. Disabled by default.")
+
+	statsFile := flag.String(
+		"stats",
+		"",
+		"Write per-turn population statistics to the given CSV file. Disabled by default.")
+
+	timings := flag.Bool(
+		"timings",
+		false,
+		"Print how long each phase of a turn and each worker took, at the end of the run. Disabled by default.")
+
+	timingsFile := flag.String(
+		"timings-json",
+		"",
+		"Write how long each phase of a turn and each worker took to the given JSON file, at the end of the run. "+
+			"Disabled by default.")
+
+	flag.StringVar(
+		&params.InputFile,
+		"input",
+		"",
+		"Specify a .pgm, .rle, .cells or Life 1.06 pattern file to load. Defaults to images/WxH.pgm.")
+
+	offset := flag.String(
+		"offset",
+		"",
+		"Specify x,y for the top left corner of the input in a larger world. Defaults to centring the input.")
+
+	var stamps util.Stamps
+	flag.Var(
+		&stamps,
+		"stamp",
+		"Stamp a pattern on the world, as \"path x,y [rotate=90|180|270] [flip] [or|xor]\". May be given more than once.")
+
+	compose := flag.String(
+		"compose",
+		"",
+		"Specify a file of stamps, one per line, to place before any given with -stamp. Disabled by default.")
+
+	flag.StringVar(
+		&params.Rule,
+		"rule",
+		"",
+		"Specify the rule in B/S notation, e.g. B36/S23. Defaults to the rule in an .rle input, otherwise B3/S23.")
+
+	flag.StringVar(
+		&params.ResumeFile,
+		"resume",
+		"",
+		"Specify a checkpoint, saved in out/ when q is pressed, to carry on the run from, with its turns and threads "+
+			"unless -turns or -t are given. Disabled by default.")
+
+	flag.Float64Var(
+		&params.Threshold,
+		"threshold",
+		0.5,
+		"Specify the fraction of a .pgm's maxval at or above which a pixel is alive. Defaults to 0.5.")
+
+	flag.StringVar(
+		&params.OutputFormat,
+		"format",
+		"pgm",
+		"Specify the format of saved images: pgm, png, rle, cells or life106. Defaults to pgm.")
+
+	flag.IntVar(
+		&params.SnapshotEvery,
+		"snapshot-every",
+		0,
+		"Save a snapshot of the world in out/ every N turns. Disabled by default.")
+
+	flag.DurationVar(
+		&params.SnapshotInterval,
+		"snapshot-interval",
+		0,
+		"Save a snapshot of the world in out/ this often, e.g. 30s. Disabled by default.")
+
+	flag.IntVar(
+		&params.SnapshotKeep,
+		"snapshot-keep",
+		0,
+		"Specify how many of the latest snapshots to keep. Defaults to keeping them all.")
+
+	var record recordOptions
+	flag.StringVar(
+		&record.filename,
+		"record",
+		"",
+		"Record the run as an animated GIF to the given file. Disabled by default.")
+
+	flag.IntVar(
+		&record.every,
+		"record-every",
+		1,
+		"Specify the number of turns between frames of the recording. Defaults to 1.")
+
+	flag.IntVar(
+		&record.from,
+		"record-from",
+		0,
+		"Specify the first turn to record. Defaults to 0.")
+
+	flag.IntVar(
+		&record.to,
+		"record-to",
+		-1,
+		"Specify the last turn to record. Defaults to recording until the end of the run.")
+
+	flag.IntVar(
+		&record.scale,
+		"record-scale",
+		1,
+		"Specify the size in pixels of each cell in the recording. Defaults to 1.")
+
+	flag.IntVar(
+		&record.delay,
+		"record-delay",
+		10,
+		"Specify how long each frame of the recording is shown, in hundredths of a second. Defaults to 10.")
+
+	palette := flag.String(
+		"record-palette",
+		"mono",
+		"Specify the colours of the recording: mono, inverted, green or amber. Defaults to mono.")
+
+	eventLogFile := flag.String(
+		"eventlog",
+		"",
+		"Record every event of the run to the given file, to be replayed with -replay. Disabled by default.")
+
+	replayFile := flag.String(
+		"replay",
+		"",
+		"Replay an event log recorded with -eventlog instead of running the game. Disabled by default.")
+
+	replaySpeed := flag.Float64(
+		"replay-speed",
+		1,
+		"Specify how many times faster than it was recorded to replay, or 0 for as fast as possible. Defaults to 1.")
+
+	replayFrom := flag.Int(
+		"replay-from",
+		0,
+		"Specify the turn to start the replay from. [ and ] seek 10 turns back and forward as it plays. Defaults to 0.")
+
+	logFlags := logging.AddFlags()
+
 	flag.Parse()
 
+	if params.ResumeFile != "" {
+		//A resumed run carries on with the checkpoint's turns and threads unless they are given again
+		given := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+		if !given["turns"] {
+			params.Turns = 0
+		}
+		if !given["t"] {
+			params.Threads = 0
+		}
+	}
+
+	if *offset != "" {
+		var cell util.Cell
+		_, err := fmt.Sscanf(*offset, "%d,%d", &cell.X, &cell.Y)
+		util.Check(err)
+		params.InputOffset = &cell
+	}
+	if *compose != "" {
+		composition, err := util.ReadStampFile(*compose)
+		util.Check(err)
+		stamps = append(composition, stamps...)
+	}
+	params.Stamps = stamps
+	//The window and the optional outputs are all sized from params, so the input file has to be looked at first,
+	//or for a replay the log
+	if *replayFile != "" {
+		header, err := eventlog.ReadHeader(*replayFile)
+		util.Check(err)
+		params.ImageWidth, params.ImageHeight = header.Width, header.Height
+		params.Threads, params.Turns = header.Threads, header.Turns
+	} else {
+		params = gol.ResolveParams(params)
+		_, err := gol.ParseRule(params.Rule)
+		util.Check(err)
+	}
+	if *terminal != "" && *terminal != "half" && *terminal != "braille" {
+		panic(fmt.Sprintf("Unknown terminal style %v", *terminal))
+	}
+	if record.filename != "" {
+		var ok bool
+		if record.palette, ok = palettes[*palette]; !ok {
+			panic(fmt.Sprintf("Unknown palette %v", *palette))
+		}
+		if record.every < 1 || record.scale < 1 {
+			panic("The recording needs at least 1 turn between frames and 1 pixel per cell")
+		}
+	}
+
+	logFlags.Setup("local")
+
 	fmt.Println("Threads:", params.Threads)
 	fmt.Println("Width:", params.ImageWidth)
 	fmt.Println("Height:", params.ImageHeight)
 
-	keyPresses := make(chan rune, 10)
+	commands := make(chan gol.Command, 10)
 	events := make(chan gol.Event, 1000)
 
-	go gol.Run(params, events, keyPresses)
-	if !(*noVis) {
-		sdl.Run(params, events, keyPresses)
+	//Ctrl-C or a terminate signal stops the run the same way as q, so the world is saved first
+	util.OnShutdownSignal(func() {
+		mainLog.Warn("Interrupted, saving the world before quitting")
+		commands <- gol.KeyPress('q')
+	})
+
+	//Optional outputs sit between the engine and the visualisation, each passing the events on to the next
+	var engineEvents = events
+	if *statsFile != "" {
+		params.ReportStats = true
+		statsEvents := make(chan gol.Event, 1000)
+		go writeStats(*statsFile, params, statsEvents, engineEvents)
+		engineEvents = statsEvents
+	}
+	if *timings || *timingsFile != "" {
+		params.ReportTimings = true
+		timingEvents := make(chan gol.Event, 1000)
+		go reportTimings(*timings, *timingsFile, timingEvents, engineEvents)
+		engineEvents = timingEvents
+	}
+	if record.filename != "" {
+		recordEvents := make(chan gol.Event, 1000)
+		go recordGif(params, record, recordEvents, engineEvents)
+		engineEvents = recordEvents
+	}
+	if *eventLogFile != "" {
+		logEvents := make(chan gol.Event, 1000)
+		go eventlog.Record(*eventLogFile, params, logEvents, engineEvents)
+		engineEvents = logEvents
+	}
+
+	if *metricsAddr != "" {
+		mainLog.Info("Serving metrics", "url", "http://"+metrics.Serve(*metricsAddr)+"/metrics")
+	}
+	if *httpAddr != "" {
+		webEvents := make(chan gol.Event, 1000)
+		address := web.Serve(*httpAddr, params, commands, webEvents, engineEvents)
+		mainLog.Info("Watch the game in a browser, or control it through /status, /pause, /resume, /snapshot, /quit "+
+			"and /world", "url", "http://"+address)
+		engineEvents = webEvents
+	}
+
+	if *replayFile != "" {
+		go eventlog.Replay(*replayFile, *replaySpeed, *replayFrom, engineEvents, commands)
+	} else {
+		go gol.RunCommands(params, engineEvents, commands)
+	}
+	if *terminal != "" {
+		term.Run(params, events, commands, *terminal == "braille")
+	} else if !(*noVis) {
+		sdl.Run(params, events, commands)
 	} else {
 		complete := false
 		for !complete {
-			event := <-events
+			event, ok := <-events
+			if !ok {
+				//The events are closed without a FinalTurnComplete when q is pressed
+				break
+			}
 			switch event.(type) {
 			case gol.FinalTurnComplete:
 				complete = true
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// meterWindow is the shortest time a Meter measures its rate over.
+const meterWindow = time.Second
+
+// Meter is a gauge of how many times a second something happens, such as turns completing.
+// The rate is measured over windows of at least a second, and falls towards zero when marks stop coming.
+type Meter struct {
+	lock  sync.Mutex
+	start time.Time
+	count int
+	rate  float64
+}
+
+// NewMeter adds a Meter to the registry.
+func (r *Registry) NewMeter(name, help string) *Meter {
+	m := &Meter{start: time.Now()}
+	r.NewGaugeFunc(name, help, m.Rate)
+	return m
+}
+
+// NewMeter adds a Meter to Default.
+func NewMeter(name, help string) *Meter {
+	return Default.NewMeter(name, help)
+}
+
+// Mark records that the thing being measured has happened once more.
+func (m *Meter) Mark() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.count++
+	now := time.Now()
+	if elapsed := now.Sub(m.start); elapsed >= meterWindow {
+		m.rate = float64(m.count) / elapsed.Seconds()
+		m.start = now
+		m.count = 0
+	}
+}
+
+// Rate gives the rate over the last window. If the current window has run on for longer than two, the rate so far in
+// it is given instead, so that a game that stops or slows down shows it.
+func (m *Meter) Rate() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if elapsed := time.Since(m.start); elapsed >= 2*meterWindow {
+		return float64(m.count) / elapsed.Seconds()
+	}
+	return m.rate
+}
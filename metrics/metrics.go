@@ -0,0 +1,338 @@
+// Package metrics keeps counters and gauges and serves them at /metrics in the Prometheus text format,
+// so that soak tests can scrape the local binary, the broker and the nodes without any client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Registry holds metrics in the order they were made, which is the order they are written in.
+type Registry struct {
+	lock     sync.Mutex
+	families []*family
+	names    map[string]bool
+}
+
+// family is one named metric, with a child for each set of label values it has been used with.
+type family struct {
+	name, help, kind string
+	labels           []string
+	newChild         func() metric
+	lock             sync.Mutex
+	children         map[string]metric
+	values           map[string][]string
+}
+
+// metric is a single value, or for a summary a sum and count, that can write its samples.
+type metric interface {
+	write(w io.Writer, name, labels string)
+}
+
+// Default is the registry served by Handler and Serve, and the one the New functions add to.
+var Default = NewRegistry()
+
+// NewRegistry makes an empty Registry. Most code should use Default instead.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+//Adds a family to the registry. Names must be unique, so making one twice is a programming error.
+func (r *Registry) add(name, help, kind string, labels []string, newChild func() metric) *family {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.names[name] {
+		panic("metric " + name + " is already registered")
+	}
+	r.names[name] = true
+	f := &family{name: name, help: help, kind: kind, labels: labels, newChild: newChild,
+		children: make(map[string]metric), values: make(map[string][]string)}
+	r.families = append(r.families, f)
+	return f
+}
+
+//Gives the child for the label values, making it the first time they are used
+func (f *family) with(values []string) metric {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %v has %v labels, but was given %v values", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	child, ok := f.children[key]
+	if !ok {
+		child = f.newChild()
+		f.children[key] = child
+		f.values[key] = append([]string(nil), values...)
+	}
+	return child
+}
+
+// Counter is a value that only goes up, such as the number of turns completed.
+type Counter struct {
+	value value
+}
+
+// Gauge is a value that can go up and down, such as the population.
+type Gauge struct {
+	value value
+}
+
+// Summary is the sum and count of observations, such as how long calls took, from which the mean can be found.
+type Summary struct {
+	lock  sync.Mutex
+	sum   float64
+	count uint64
+}
+
+// value is a float64 that can be changed from many goroutines at once.
+type value struct {
+	lock  sync.Mutex
+	value float64
+}
+
+func (v *value) add(delta float64) {
+	v.lock.Lock()
+	v.value += delta
+	v.lock.Unlock()
+}
+
+func (v *value) get() float64 {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return v.value
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() {
+	c.value.add(1)
+}
+
+// Add adds delta, which must not be negative, to the counter.
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("counters can't go down")
+	}
+	c.value.add(delta)
+}
+
+// Set changes the gauge to v.
+func (g *Gauge) Set(v float64) {
+	g.value.lock.Lock()
+	g.value.value = v
+	g.value.lock.Unlock()
+}
+
+// Add adds delta, which may be negative, to the gauge.
+func (g *Gauge) Add(delta float64) {
+	g.value.add(delta)
+}
+
+// Observe adds v to the sum and one to the count.
+func (s *Summary) Observe(v float64) {
+	s.lock.Lock()
+	s.sum += v
+	s.count++
+	s.lock.Unlock()
+}
+
+// ObserveSince observes the time since start in seconds.
+func (s *Summary) ObserveSince(start time.Time) {
+	s.Observe(time.Since(start).Seconds())
+}
+
+func (c *Counter) write(w io.Writer, name, labels string) {
+	writeSample(w, name, labels, c.value.get())
+}
+
+func (g *Gauge) write(w io.Writer, name, labels string) {
+	writeSample(w, name, labels, g.value.get())
+}
+
+func (s *Summary) write(w io.Writer, name, labels string) {
+	s.lock.Lock()
+	sum, count := s.sum, s.count
+	s.lock.Unlock()
+	writeSample(w, name+"_sum", labels, sum)
+	writeSample(w, name+"_count", labels, float64(count))
+}
+
+// gaugeFunc is a gauge whose value is found when it is written.
+type gaugeFunc func() float64
+
+func (f gaugeFunc) write(w io.Writer, name, labels string) {
+	writeSample(w, name, labels, f())
+}
+
+// CounterVec, GaugeVec and SummaryVec are metrics split by labels, such as a counter for each worker.
+type CounterVec struct{ family *family }
+type GaugeVec struct{ family *family }
+type SummaryVec struct{ family *family }
+
+// With gives the counter for the label values, given in the order of the label names.
+func (v CounterVec) With(values ...string) *Counter {
+	return v.family.with(values).(*Counter)
+}
+
+// With gives the gauge for the label values, given in the order of the label names.
+func (v GaugeVec) With(values ...string) *Gauge {
+	return v.family.with(values).(*Gauge)
+}
+
+// With gives the summary for the label values, given in the order of the label names.
+func (v SummaryVec) With(values ...string) *Summary {
+	return v.family.with(values).(*Summary)
+}
+
+// NewCounter, NewGauge and NewSummary add a metric without labels to the registry.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).With()
+}
+
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).With()
+}
+
+func (r *Registry) NewSummary(name, help string) *Summary {
+	return r.NewSummaryVec(name, help).With()
+}
+
+// NewGaugeFunc adds a gauge that calls f for its value whenever the metrics are written.
+func (r *Registry) NewGaugeFunc(name, help string, f func() float64) {
+	r.add(name, help, "gauge", nil, func() metric { return gaugeFunc(f) }).with(nil)
+}
+
+// NewCounterVec, NewGaugeVec and NewSummaryVec add a metric split by the labels to the registry.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) CounterVec {
+	return CounterVec{r.add(name, help, "counter", labels, func() metric { return new(Counter) })}
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) GaugeVec {
+	return GaugeVec{r.add(name, help, "gauge", labels, func() metric { return new(Gauge) })}
+}
+
+func (r *Registry) NewSummaryVec(name, help string, labels ...string) SummaryVec {
+	return SummaryVec{r.add(name, help, "summary", labels, func() metric { return new(Summary) })}
+}
+
+// NewCounter, NewGauge, NewSummary, NewGaugeFunc and the Vec versions add metrics to Default.
+func NewCounter(name, help string) *Counter {
+	return Default.NewCounter(name, help)
+}
+
+func NewGauge(name, help string) *Gauge {
+	return Default.NewGauge(name, help)
+}
+
+func NewSummary(name, help string) *Summary {
+	return Default.NewSummary(name, help)
+}
+
+func NewGaugeFunc(name, help string, f func() float64) {
+	Default.NewGaugeFunc(name, help, f)
+}
+
+func NewCounterVec(name, help string, labels ...string) CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+func NewGaugeVec(name, help string, labels ...string) GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+func NewSummaryVec(name, help string, labels ...string) SummaryVec {
+	return Default.NewSummaryVec(name, help, labels...)
+}
+
+// Write writes every metric in the Prometheus text exposition format.
+// A labelled metric that hasn't been used yet is written with just its help and type.
+func (r *Registry) Write(w io.Writer) {
+	r.lock.Lock()
+	families := append([]*family(nil), r.families...)
+	r.lock.Unlock()
+
+	for _, f := range families {
+		_, _ = fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", f.name, escape(f.help, false), f.name, f.kind)
+		f.lock.Lock()
+		keys := make([]string, 0, len(f.children))
+		for key := range f.children {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			f.children[key].write(w, f.name, formatLabels(f.labels, f.values[key]))
+		}
+		f.lock.Unlock()
+	}
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// Handler serves the metrics in Default.
+func Handler() http.Handler {
+	return Default
+}
+
+// Serve listens on addr, such as ":9100", and serves the metrics in Default at /metrics in the background.
+// It returns the address being listened on.
+func Serve(addr string) string {
+	listener, err := net.Listen("tcp", addr)
+	util.Check(err)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Default)
+	go func() {
+		util.Check(http.Serve(listener, mux))
+	}()
+	return listener.Addr().String()
+}
+
+//Gives the labels of a sample in braces, or nothing if it has none
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i := range names {
+		pairs[i] = names[i] + `="` + escape(values[i], true) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func writeSample(w io.Writer, name, labels string, v float64) {
+	_, _ = fmt.Fprintf(w, "%v%v %v\n", name, labels, formatValue(v))
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+//Escapes backslashes and new lines, and double quotes in label values, as the text format asks
+func escape(text string, quotes bool) string {
+	text = strings.Replace(text, `\`, `\\`, -1)
+	text = strings.Replace(text, "\n", `\n`, -1)
+	if quotes {
+		text = strings.Replace(text, `"`, `\"`, -1)
+	}
+	return text
+}
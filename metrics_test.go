@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/metrics"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestMetrics checks the text format written for each kind of metric, then runs the 16x16 image for 100 turns and
+// checks that the engine's metrics count them.
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.NewCounter("test_total", "A counter.").Add(3)
+	calls := registry.NewSummaryVec("test_seconds", "A summary.", "node")
+	calls.With("2").Observe(0.5)
+	calls.With("1").Observe(0.25)
+	calls.With("1").Observe(0.5)
+	registry.NewGaugeVec("test_gauge", "A gauge with\na \\ in its help.", "name").With(`say "hi"`).Set(-1.5)
+	registry.NewGaugeVec("test_unused", "Never given a value.", "name")
+
+	var written bytes.Buffer
+	registry.Write(&written)
+	expected := `# HELP test_total A counter.
+# TYPE test_total counter
+test_total 3
+# HELP test_seconds A summary.
+# TYPE test_seconds summary
+test_seconds_sum{node="1"} 0.75
+test_seconds_count{node="1"} 2
+test_seconds_sum{node="2"} 0.5
+test_seconds_count{node="2"} 1
+# HELP test_gauge A gauge with\na \\ in its help.
+# TYPE test_gauge gauge
+test_gauge{name="say \"hi\""} -1.5
+# HELP test_unused Never given a value.
+# TYPE test_unused gauge
+`
+	if written.String() != expected {
+		t.Errorf("Expected the metrics\n%v\ngot\n%v", expected, written.String())
+	}
+
+	server := httptest.NewServer(metrics.Handler())
+	defer server.Close()
+	before := scrape(t, server.URL)
+
+	p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 16, ImageHeight: 16}
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	for range events {
+	}
+
+	after := scrape(t, server.URL)
+	if turns := after["gol_turns_completed_total"] - before["gol_turns_completed_total"]; turns != 100 {
+		t.Errorf("Expected 100 more turns to be counted, got %v", turns)
+	}
+	expectedAlive := len(readAliveCells("check/images/16x16x100.pgm", 16, 16))
+	if alive := after["gol_population"]; alive != float64(expectedAlive) {
+		t.Errorf("Expected the population after turn 100 to be %v, got %v", expectedAlive, alive)
+	}
+	for _, sample := range []string{`gol_worker_compute_seconds_total{worker="3"}`, `gol_io_bytes_total{direction="read"}`,
+		`gol_io_bytes_total{direction="written"}`} {
+		if after[sample] <= before[sample] {
+			t.Errorf("Expected %v to go up, went from %v to %v", sample, before[sample], after[sample])
+		}
+	}
+}
+
+//Fetches the metrics and gives the value of each sample
+func scrape(t *testing.T, url string) map[string]float64 {
+	response, err := http.Get(url + "/metrics")
+	util.Check(err)
+	body, err := ioutil.ReadAll(response.Body)
+	util.Check(err)
+	util.Check(response.Body.Close())
+	samples := make(map[string]float64)
+	for _, line := range strings.Split(string(body), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		space := strings.LastIndex(line, " ")
+		value, err := strconv.ParseFloat(line[space+1:], 64)
+		if err != nil {
+			t.Fatalf("Expected a number at the end of %q", line)
+		}
+		samples[line[:space]] = value
+	}
+	return samples
+}
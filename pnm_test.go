@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestPnm rewrites the 16x16 image as ASCII and binary PGM and PBM files, with header comments,
+// 16-bit values and grey pixels, then checks each one gives the expected board after 1 turn.
+func TestPnm(t *testing.T) {
+	file, err := os.Open("images/16x16.pgm")
+	util.Check(err)
+	_, world, err := util.ReadPNM(file, util.DefaultThreshold)
+	util.Check(err)
+	util.Check(file.Close())
+
+	dir, err := ioutil.TempDir("", "pnm")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+
+	variants := map[string]func(*bytes.Buffer){
+		"p2-comments.pgm": func(b *bytes.Buffer) {
+			b.WriteString("P2\n# Created by GIMP\n16 # width\n16\n# maxval follows\n255\n")
+			writePixels(b, world, "# end of row\n", func(alive bool) string {
+				if alive {
+					return "255\n"
+				}
+				return "0 "
+			})
+		},
+		"p5-16bit.pgm": func(b *bytes.Buffer) {
+			b.WriteString("P5\n# 16-bit\n16 16\n65535\n")
+			writePixels(b, world, "", func(alive bool) string {
+				if alive {
+					return "\xff\xff"
+				}
+				return "\x00\x00"
+			})
+		},
+		//Grey pixels either side of the threshold, with values that are whitespace bytes
+		"p5-grey.pgm": func(b *bytes.Buffer) {
+			b.WriteString("P5 16 16 15\n")
+			writePixels(b, world, "", func(alive bool) string {
+				if alive {
+					return "\x0c"
+				}
+				return "\x0a"
+			})
+		},
+		"p1.pbm": func(b *bytes.Buffer) {
+			b.WriteString("P1\n# bitmap\n16 16\n")
+			writePixels(b, world, "\n", func(alive bool) string {
+				if alive {
+					return "1"
+				}
+				return "0"
+			})
+		},
+		"p4.pbm": func(b *bytes.Buffer) {
+			b.WriteString("P4\n16 16\n")
+			for _, row := range world {
+				packed := make([]byte, 2)
+				for x, cell := range row {
+					if cell != 0 {
+						packed[x/8] |= 0x80 >> uint(x%8)
+					}
+				}
+				b.Write(packed)
+			}
+		},
+	}
+
+	expectedAlive := readAliveCells("check/images/16x16x1.pgm", 16, 16)
+	for name, write := range variants {
+		var contents bytes.Buffer
+		write(&contents)
+		input := filepath.Join(dir, name)
+		util.Check(ioutil.WriteFile(input, contents.Bytes(), 0644))
+
+		p := gol.Params{Turns: 1, Threads: 4, ImageWidth: 16, ImageHeight: 16, InputFile: input, Threshold: 0.75}
+		t.Run(fmt.Sprintf("%v-%dx%dx%d-%d", name, p.ImageWidth, p.ImageHeight, p.Turns, p.Threads), func(t *testing.T) {
+			events := make(chan gol.Event)
+			go gol.Run(p, events, nil)
+			var cells []util.Cell
+			for event := range events {
+				switch e := event.(type) {
+				case gol.FinalTurnComplete:
+					cells = e.Alive
+				}
+			}
+			assertEqualBoard(t, cells, expectedAlive, p)
+		})
+	}
+}
+
+//Helper function of TestPnm. Writes a pixel for every cell of the world, ending each row with rowEnd.
+func writePixels(b *bytes.Buffer, world [][]byte, rowEnd string, pixel func(alive bool) string) {
+	for _, row := range world {
+		for _, cell := range row {
+			b.WriteString(pixel(cell != 0))
+		}
+		b.WriteString(rowEnd)
+	}
+}
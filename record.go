@@ -0,0 +1,132 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// palettes are the colour schemes a recording can use. The first colour is dead cells, the second alive ones.
+var palettes = map[string]color.Palette{
+	"mono":     {color.Black, color.White},
+	"inverted": {color.White, color.Black},
+	"green":    {color.RGBA{0x00, 0x1e, 0x00, 0xff}, color.RGBA{0x33, 0xff, 0x33, 0xff}},
+	"amber":    {color.RGBA{0x1e, 0x10, 0x00, 0xff}, color.RGBA{0xff, 0xb0, 0x00, 0xff}},
+}
+
+// recordOptions configures a GIF recording of a run.
+type recordOptions struct {
+	filename string
+	// every is the number of turns between frames.
+	every int
+	// from and to are the first and last turns to record. A negative to records until the end of the run.
+	from, to int
+	// scale is the width and height in pixels of each cell.
+	scale int
+	// delay is the time each frame is shown for, in hundredths of a second.
+	delay   int
+	palette color.Palette
+}
+
+// recordGif passes every event from in through to out, keeping track of the world from CellFlipped events
+// and saving it as a frame of an animated GIF at the end of the turns being recorded.
+// The frames are kept in memory until the run ends, so long runs are best recorded over a turn range.
+func recordGif(p gol.Params, options recordOptions, in <-chan gol.Event, out chan<- gol.Event) {
+	defer close(out)
+
+	world := make([][]bool, p.ImageHeight)
+	for i := range world {
+		world[i] = make([]bool, p.ImageWidth)
+	}
+	animation := &gif.GIF{}
+	//The world loaded from the file has no TurnComplete of its own, so it is saved once turn 1 begins
+	startPending := options.from == 0
+	saved := false
+	turn := 0
+
+	recording := func(turn int) bool {
+		return turn >= options.from && (options.to < 0 || turn <= options.to) && (turn-options.from)%options.every == 0
+	}
+	save := func() {
+		if saved {
+			return
+		}
+		saved = true
+		if startPending {
+			addFrame(animation, world, options)
+			startPending = false
+		}
+		if len(animation.Image) == 0 {
+			recordLog.Warn("The recording has no frames in the turns asked for", "turn", turn, "file", options.filename)
+			return
+		}
+		file, err := os.Create(options.filename)
+		util.Check(err)
+		defer file.Close()
+		util.Check(gif.EncodeAll(file, animation))
+		recordLog.Info("Recording output done", "turn", turn, "file", options.filename,
+			"frames", len(animation.Image))
+	}
+
+	for event := range in {
+		if startPending {
+			switch event.(type) {
+			case gol.CellFlipped, gol.TurnComplete, gol.FinalTurnComplete:
+				if event.GetCompletedTurns() > 0 || isFinal(event) {
+					addFrame(animation, world, options)
+					startPending = false
+				}
+			}
+		}
+
+		turn = event.GetCompletedTurns()
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			world[e.Cell.Y][e.Cell.X] = !world[e.Cell.Y][e.Cell.X]
+		case gol.TurnComplete:
+			if recording(e.CompletedTurns) {
+				addFrame(animation, world, options)
+			}
+		case gol.FinalTurnComplete:
+			save()
+		}
+		out <- event
+	}
+	//q stops the run without a FinalTurnComplete
+	save()
+}
+
+//Reports whether the event is the FinalTurnComplete at the end of a run
+func isFinal(event gol.Event) bool {
+	_, ok := event.(gol.FinalTurnComplete)
+	return ok
+}
+
+//Helper function of recordGif. Draws the world as the next frame of the animation.
+func addFrame(animation *gif.GIF, world [][]bool, options recordOptions) {
+	height := len(world)
+	width := 0
+	if height > 0 {
+		width = len(world[0])
+	}
+
+	frame := image.NewPaletted(image.Rect(0, 0, width*options.scale, height*options.scale), options.palette)
+	for y, row := range world {
+		for x, alive := range row {
+			if !alive {
+				continue
+			}
+			for dy := 0; dy < options.scale; dy++ {
+				for dx := 0; dx < options.scale; dx++ {
+					frame.SetColorIndex(x*options.scale+dx, y*options.scale+dy, 1)
+				}
+			}
+		}
+	}
+	animation.Image = append(animation.Image, frame)
+	animation.Delay = append(animation.Delay, options.delay)
+}
@@ -0,0 +1,131 @@
+// Package reference is a slow, obviously correct Game of Life, used to make the fixtures in check/ that the engines
+// are tested against. Every cell looks at each of its eight neighbours in turn, so it is easy to check by eye, if not
+// quick to run.
+package reference
+
+import (
+	"fmt"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// Topology is how the edges of the world join up.
+type Topology string
+
+// The topologies a world can have. The engines all run on a torus.
+const (
+	// Torus joins the left edge to the right and the top to the bottom.
+	Torus Topology = "torus"
+	// Cylinder joins the left edge to the right. Beyond the top and bottom every cell is dead.
+	Cylinder Topology = "cylinder"
+	// Plane joins nothing up. Beyond every edge every cell is dead.
+	Plane Topology = "plane"
+)
+
+// ParseTopology reads a topology by name. An empty name is a torus.
+func ParseTopology(name string) (Topology, error) {
+	switch Topology(name) {
+	case "", Torus:
+		return Torus, nil
+	case Cylinder, Plane:
+		return Topology(name), nil
+	}
+	return "", fmt.Errorf("unknown topology %q, expected torus, cylinder or plane", name)
+}
+
+// World is a grid of cells indexed [y][x], true where the cell is alive.
+type World [][]bool
+
+// NewWorld makes a world of dead cells.
+func NewWorld(width, height int) World {
+	world := make(World, height)
+	for y := range world {
+		world[y] = make([]bool, width)
+	}
+	return world
+}
+
+// FromBytes makes a world from one as the engines keep it, where any cell that isn't 0 is alive.
+func FromBytes(cells [][]byte) World {
+	world := make(World, len(cells))
+	for y, row := range cells {
+		world[y] = make([]bool, len(row))
+		for x, cell := range row {
+			world[y][x] = cell != 0
+		}
+	}
+	return world
+}
+
+// Bytes gives the world as the engines keep it, with 255 for alive cells and 0 for dead ones.
+func (world World) Bytes() [][]byte {
+	cells := make([][]byte, len(world))
+	for y, row := range world {
+		cells[y] = make([]byte, len(row))
+		for x, alive := range row {
+			if alive {
+				cells[y][x] = 255
+			}
+		}
+	}
+	return cells
+}
+
+// Alive counts the alive cells.
+func (world World) Alive() int {
+	count := 0
+	for _, row := range world {
+		for _, alive := range row {
+			if alive {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// IsAlive reports whether the cell at x, y is alive, joining the edges up as the topology says.
+// A cell beyond an edge that isn't joined up is dead.
+func (world World) IsAlive(x, y int, topology Topology) bool {
+	height := len(world)
+	width := len(world[0])
+	if topology == Torus || topology == Cylinder {
+		x = (x + width) % width
+	}
+	if topology == Torus {
+		y = (y + height) % height
+	}
+	if x < 0 || x >= width || y < 0 || y >= height {
+		return false
+	}
+	return world[y][x]
+}
+
+// Neighbours counts the alive cells among the eight around x, y.
+func (world World) Neighbours(x, y int, topology Topology) int {
+	count := 0
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if (dx != 0 || dy != 0) && world.IsAlive(x+dx, y+dy, topology) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Step gives the world after one turn of the rule. The world itself is left alone.
+func Step(world World, rule gol.Rule, topology Topology) World {
+	next := NewWorld(len(world[0]), len(world))
+	for y := range world {
+		for x := range world[y] {
+			neighbours := world.Neighbours(x, y, topology)
+			if world[y][x] {
+				next[y][x] = rule.Survival[neighbours]
+			} else {
+				next[y][x] = rule.Birth[neighbours]
+			}
+		}
+	}
+	return next
+}
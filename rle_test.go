@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestRle loads a glider from an RLE file into a 16x16 world, runs it for 4 turns on 1-4 worker threads
+// and checks the glider has moved one cell diagonally, both in the final event and the saved RLE file.
+func TestRle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rle")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+	input := filepath.Join(dir, "glider.rle")
+	util.Check(ioutil.WriteFile(input, []byte("#N Glider\nx = 3, y = 3, rule = B3/S23\nbo$2bo$3o!\n"), 0644))
+
+	expected := []util.Cell{{X: 2, Y: 1}, {X: 3, Y: 2}, {X: 1, Y: 3}, {X: 2, Y: 3}, {X: 3, Y: 3}}
+	for threads := 1; threads <= 4; threads++ {
+		p := gol.Params{
+			Turns:        4,
+			Threads:      threads,
+			ImageWidth:   16,
+			ImageHeight:  16,
+			InputFile:    input,
+			InputOffset:  &util.Cell{},
+			OutputFormat: "rle",
+		}
+		testName := fmt.Sprintf("%dx%dx%d-%d", p.ImageWidth, p.ImageHeight, p.Turns, p.Threads)
+		t.Run(testName, func(t *testing.T) {
+			events := make(chan gol.Event)
+			go gol.Run(p, events, nil)
+			var cells []util.Cell
+			for event := range events {
+				switch e := event.(type) {
+				case gol.FinalTurnComplete:
+					cells = e.Alive
+				}
+			}
+			assertEqualBoard(t, cells, expected, p)
+
+			file, err := os.Open(fmt.Sprintf("out/%vx%vx%v.rle", p.ImageWidth, p.ImageHeight, p.Turns))
+			util.Check(err)
+			defer file.Close()
+			pattern, err := util.ReadRLE(file)
+			util.Check(err)
+			if pattern.Rule != gol.ConwayRule {
+				t.Errorf("Expected rule %v in the saved file, got %v", gol.ConwayRule, pattern.Rule)
+			}
+			assertEqualBoard(t, pattern.Alive, expected, p)
+		})
+	}
+}
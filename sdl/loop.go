@@ -1,34 +1,58 @@
 package sdl
 
 import (
-	"fmt"
 	"github.com/veandco/go-sdl2/sdl"
 	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/logging"
 )
 
-func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) {
+var sdlLog = logging.Component("sdl")
+
+func Run(p gol.Params, events <-chan gol.Event, commands chan<- gol.Command) {
 	w := NewWindow(int32(p.ImageWidth), int32(p.ImageHeight))
+	w.hud.threads = p.Threads
 
 sdlLoop:
 	for {
 		event := w.PollEvent()
 		if event != nil {
+			if w.HandleViewEvent(event) {
+				w.RenderFrame()
+			}
+			if edit, ok := w.EditEvent(event); ok {
+				commands <- edit
+			}
 			switch e := event.(type) {
 			case *sdl.KeyboardEvent:
 				switch e.Keysym.Sym {
 				case sdl.K_p:
 					//When p is pressed, pause the processing and print the current turn that is being processed
 					//If p is pressed again resume the processing
-					keyPresses <- 'p'
-					fmt.Println("P pressed")
+					commands <- gol.KeyPress('p')
+					sdlLog.Debug("Key pressed", "key", "p", "turn", w.hud.turn)
 				case sdl.K_s:
 					//When s is pressed, we need to generate a PGM file with the current state of the board
-					keyPresses <- 's'
+					commands <- gol.KeyPress('s')
 				case sdl.K_q:
 					//When q is pressed, generate a PGM file with the current state of the board then terminate
-					keyPresses <- 'q'
+					commands <- gol.KeyPress('q')
 				case sdl.K_k:
-					keyPresses <- 'k'
+					commands <- gol.KeyPress('k')
+				case sdl.K_n:
+					//When n is pressed while paused, run exactly one more turn
+					commands <- gol.KeyPress('n')
+				case sdl.K_EQUALS, sdl.K_PLUS, sdl.K_KP_PLUS:
+					//+ and - step the target speed up and down, with full speed above the fastest
+					commands <- gol.KeyPress('+')
+				case sdl.K_MINUS, sdl.K_KP_MINUS:
+					commands <- gol.KeyPress('-')
+				case sdl.K_LEFTBRACKET, sdl.K_RIGHTBRACKET:
+					//[ and ] move a replay back and forward
+					step := gol.SeekStep
+					if e.Keysym.Sym == sdl.K_LEFTBRACKET {
+						step = -step
+					}
+					commands <- gol.Seek(step)
 				}
 			}
 		}
@@ -42,14 +66,31 @@ sdlLoop:
 			case gol.CellFlipped:
 				w.FlipPixel(e.Cell.X, e.Cell.Y)
 			case gol.TurnComplete:
+				w.AgeCells()
+				w.hud.turn = e.CompletedTurns
+				w.RenderFrame()
+			case gol.CellsEdited:
+				w.RenderFrame()
+			case gol.StateChange:
+				w.hud.paused = e.NewState == gol.Paused
+				w.RenderFrame()
+				sdlLog.Debug("State changed", "turn", e.CompletedTurns, "state", e.NewState)
+			case gol.AliveCellsCount:
+				//The engine is asked for the world when the counts differ, and the window made to match it
+				if w.CheckCount(e.CompletedTurns, e.CellsCount) {
+					commands <- gol.RequestWorld{}
+				}
+			case gol.WorldState:
+				w.Resync(e.CompletedTurns, e.Alive)
 				w.RenderFrame()
 			case gol.FinalTurnComplete:
+				w.Resync(e.CompletedTurns, e.Alive)
+				w.RenderFrame()
 				w.Destroy()
 				break sdlLoop
 			default:
-				if len(event.String()) > 0 {
-					fmt.Printf("Completed Turns %-8v%v\n", event.GetCompletedTurns(), event)
-				}
+				//The HUD shows the population as it changes, so anything else is only logged
+				sdlLog.Debug("Event", "turn", event.GetCompletedTurns(), "event", event)
 			}
 		default:
 			break
@@ -0,0 +1,177 @@
+package sdl
+
+import (
+	"math"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// The zoom limits. A zoom of z draws each cell 2^z pixels wide, so negative zooms draw several cells per pixel.
+const (
+	minZoom = -4
+	maxZoom = 6
+)
+
+// minWindowSize is the smallest width or height of the window, so that long thin worlds can still be seen.
+const minWindowSize = 256
+
+// The grey levels of the parts of the window that aren't cells.
+const (
+	outsideColour = 0x28
+	gridColour    = 0x40
+)
+
+// viewport is the part of the world shown in the window, and how big it is drawn.
+type viewport struct {
+	worldWidth, worldHeight int
+	// width and height are the size of the window in pixels.
+	width, height int
+	zoom          int
+	// x and y are the world coordinates of the top left corner of the window.
+	x, y float64
+	grid bool
+}
+
+//Gives the number of pixels across each cell, which is less than 1 when the view is zoomed out
+func (v *viewport) scale() float64 {
+	return math.Ldexp(1, v.zoom)
+}
+
+//Zooms to the largest size that shows the whole world and centres it in the window
+func (v *viewport) fit() {
+	v.zoom = maxZoom
+	for v.zoom > minZoom && (float64(v.worldWidth)*v.scale() > float64(v.width) ||
+		float64(v.worldHeight)*v.scale() > float64(v.height)) {
+		v.zoom--
+	}
+	v.x = (float64(v.worldWidth) - float64(v.width)/v.scale()) / 2
+	v.y = (float64(v.worldHeight) - float64(v.height)/v.scale()) / 2
+}
+
+//Zooms in by steps, or out for negative steps, keeping the cell under the pixel at px, py where it is
+func (v *viewport) zoomAt(px, py, steps int) {
+	cellX, cellY := v.worldAt(px, py)
+	v.zoom += steps
+	if v.zoom < minZoom {
+		v.zoom = minZoom
+	}
+	if v.zoom > maxZoom {
+		v.zoom = maxZoom
+	}
+	v.x = cellX - float64(px)/v.scale()
+	v.y = cellY - float64(py)/v.scale()
+}
+
+//Moves the world by dx, dy pixels, as when it is dragged
+func (v *viewport) pan(dx, dy int) {
+	v.x -= float64(dx) / v.scale()
+	v.y -= float64(dy) / v.scale()
+}
+
+//Gives the world coordinates under the pixel at px, py
+func (v *viewport) worldAt(px, py int) (float64, float64) {
+	return v.x + float64(px)/v.scale(), v.y + float64(py)/v.scale()
+}
+
+//Gives the cell under the pixel at px, py, which may be outside the world
+func (v *viewport) cellAt(px, py int) util.Cell {
+	x, y := v.worldAt(px, py)
+	return util.Cell{X: int(math.Floor(x)), Y: int(math.Floor(y))}
+}
+
+//Draws the visible part of the world into pixels, which hold 4 bytes for each pixel of the window, colouring cells
+//by age with the palette. Grid lines are only drawn when cells are at least 4 pixels across.
+func (v *viewport) draw(state *cellState, colours *palette, trails bool, pixels []byte) {
+	scale := v.scale()
+	cellsPerPixel := 1
+	if v.zoom < 0 {
+		cellsPerPixel = 1 << uint(-v.zoom)
+	}
+	showGrid := v.grid && v.zoom >= 2
+
+	for py := 0; py < v.height; py++ {
+		worldY := v.y + float64(py)/scale
+		cellY := int(math.Floor(worldY))
+		gridRow := showGrid && worldY-float64(cellY) < 1/scale
+		for px := 0; px < v.width; px++ {
+			worldX := v.x + float64(px)/scale
+			cellX := int(math.Floor(worldX))
+
+			var colour [3]byte
+			switch {
+			case cellX < 0 || cellY < 0 || cellX >= v.worldWidth || cellY >= v.worldHeight:
+				colour = [3]byte{outsideColour, outsideColour, outsideColour}
+			case gridRow || (showGrid && worldX-float64(cellX) < 1/scale):
+				colour = [3]byte{gridColour, gridColour, gridColour}
+			default:
+				cell := v.pick(state, cellX, cellY, cellsPerPixel)
+				colour = colours.colour(state.alive[cell], state.age[cell], trails)
+			}
+			//The texture is ARGB8888, which is stored blue first
+			i := 4 * (py*v.width + px)
+			pixels[i+0] = colour[2]
+			pixels[i+1] = colour[1]
+			pixels[i+2] = colour[0]
+			pixels[i+3] = 0xFF
+		}
+	}
+}
+
+//Gives the index of the cell that stands for the size x size block with its top left corner at x, y:
+//the youngest alive cell, or the most recently dead cell if none are alive
+func (v *viewport) pick(state *cellState, x, y, size int) int {
+	best := y*v.worldWidth + x
+	for dy := 0; dy < size && y+dy < v.worldHeight; dy++ {
+		for dx := 0; dx < size && x+dx < v.worldWidth; dx++ {
+			i := (y+dy)*v.worldWidth + x + dx
+			if state.alive[i] != state.alive[best] {
+				if state.alive[i] {
+					best = i
+				}
+			} else if state.age[i] < state.age[best] {
+				best = i
+			}
+		}
+	}
+	return best
+}
+
+//Picks a window size for a width x height world: small worlds are scaled up to around 512 pixels
+//and large ones are scaled down to fit in 1024. Neither side is less than minWindowSize.
+func windowSize(width, height int) (int, int) {
+	largest := width
+	if height > largest {
+		largest = height
+	}
+	zoom := 0
+	for zoom < maxZoom && largest<<uint(zoom+1) <= 768 {
+		zoom++
+	}
+	for zoom > minZoom && float64(largest)*math.Ldexp(1, zoom) > 1024 {
+		zoom--
+	}
+	scale := math.Ldexp(1, zoom)
+	return atLeast(int(math.Ceil(float64(width)*scale)), minWindowSize),
+		atLeast(int(math.Ceil(float64(height)*scale)), minWindowSize)
+}
+
+func atLeast(value, minimum int) int {
+	if value < minimum {
+		return minimum
+	}
+	return value
+}
+
+//Lists the cells on a line from one cell to another, leaving out the first, so that a fast drag doesn't skip any
+func cellsBetween(from, to util.Cell) []util.Cell {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	steps := int(math.Max(math.Abs(float64(dx)), math.Abs(float64(dy))))
+	cells := make([]util.Cell, 0, steps)
+	for i := 1; i <= steps; i++ {
+		cells = append(cells, util.Cell{
+			X: from.X + int(math.Round(float64(dx*i)/float64(steps))),
+			Y: from.Y + int(math.Round(float64(dy*i)/float64(steps))),
+		})
+	}
+	return cells
+}
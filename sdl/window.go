@@ -2,45 +2,79 @@ package sdl
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
+	"uk.ac.bris.cs/gameoflife/gol"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
+// Window shows the world through a viewport that can be zoomed with the mouse wheel and panned by dragging.
+// Cells are edited by clicking or dragging with the left button. A HUD showing the turn, population and speed is
+// drawn over the top left corner. The window keeps its own model of the world, so cells are coloured by their age.
+// Width and Height are the size of the world, not the window. Only the visible part of the world is drawn,
+// so pixels holds one entry per pixel of the window whatever size the world is.
 type Window struct {
 	Width, Height int32
 	window        *sdl.Window
 	renderer      *sdl.Renderer
 	texture       *sdl.Texture
 	pixels        []byte
+	state         cellState
+	palette       int
+	trails        bool
+	view          viewport
+	hud           hud
+	panning       bool
+	// painting is set while the left button is held, setting every cell the pointer passes over alive or dead.
+	painting   bool
+	paintAlive bool
+	lastCell   util.Cell
 }
 
 func filterEvent(e sdl.Event, userdata interface{}) bool {
-	return e.GetType() == sdl.KEYDOWN || e.GetType() == sdl.QUIT
+	switch e.GetType() {
+	case sdl.KEYDOWN, sdl.QUIT, sdl.MOUSEWHEEL, sdl.MOUSEBUTTONDOWN, sdl.MOUSEBUTTONUP, sdl.MOUSEMOTION:
+		return true
+	}
+	return false
 }
 
 func NewWindow(width, height int32) *Window {
+	windowWidth, windowHeight := windowSize(int(width), int(height))
 	err := sdl.Init(sdl.INIT_EVERYTHING)
 	util.Check(err)
-	window, err := sdl.CreateWindow("GOL GUI", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, width, height, sdl.WINDOW_SHOWN)
+	window, err := sdl.CreateWindow("GOL GUI", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED,
+		int32(windowWidth), int32(windowHeight), sdl.WINDOW_SHOWN)
 	util.Check(err)
 	renderer, err := sdl.CreateRenderer(window, -1, sdl.WINDOW_SHOWN)
 	util.Check(err)
 	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "linear")
-	err = renderer.SetLogicalSize(width, height)
+	err = renderer.SetLogicalSize(int32(windowWidth), int32(windowHeight))
 	util.Check(err)
-	texture, err := renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STATIC, width, height)
+	texture, err := renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STATIC,
+		int32(windowWidth), int32(windowHeight))
 	util.Check(err)
 
 	sdl.SetEventFilterFunc(filterEvent, nil)
-	return &Window{
-		width,
-		height,
-		window,
-		renderer,
-		texture,
-		make([]byte, width*height*4),
+	w := &Window{
+		Width:    width,
+		Height:   height,
+		window:   window,
+		renderer: renderer,
+		texture:  texture,
+		pixels:   make([]byte, windowWidth*windowHeight*4),
+		state:    newCellState(int(width), int(height)),
+		view: viewport{
+			worldWidth:  int(width),
+			worldHeight: int(height),
+			width:       windowWidth,
+			height:      windowHeight,
+		},
+		hud: hud{visible: true, palette: palettes[0].name},
 	}
+	w.view.fit()
+	return w
 }
 
 func (w *Window) Destroy() {
@@ -54,7 +88,12 @@ func (w *Window) Destroy() {
 }
 
 func (w *Window) RenderFrame() {
-	err := w.texture.Update(nil, w.pixels, int(w.Width*4))
+	w.view.draw(&w.state, palettes[w.palette], w.trails, w.pixels)
+	w.hud.frameDrawn(time.Now())
+	if w.hud.visible {
+		w.hud.draw(w.pixels, w.view.width, w.view.height, w.state.count)
+	}
+	err := w.texture.Update(nil, w.pixels, w.view.width*4)
 	util.Check(err)
 	err = w.renderer.Clear()
 	util.Check(err)
@@ -67,12 +106,89 @@ func (w *Window) PollEvent() sdl.Event {
 	return sdl.PollEvent()
 }
 
+// HandleViewEvent zooms, pans or changes how the world is drawn for mouse and view key events.
+// The mouse wheel zooms around the pointer, dragging with the right or middle button pans, f fits the world to the window and
+// g turns grid lines on and off, h shows or hides the HUD, c changes the palette and t turns the trails left by dead
+// cells on and off. It reports whether the frame needs drawing again.
+func (w *Window) HandleViewEvent(event sdl.Event) bool {
+	switch e := event.(type) {
+	case *sdl.MouseWheelEvent:
+		x, y, _ := sdl.GetMouseState()
+		w.view.zoomAt(int(x), int(y), int(e.Y))
+		return true
+	case *sdl.MouseButtonEvent:
+		if e.Button != sdl.BUTTON_LEFT {
+			w.panning = e.State == sdl.PRESSED
+		}
+	case *sdl.MouseMotionEvent:
+		if w.panning {
+			w.view.pan(int(e.XRel), int(e.YRel))
+			return true
+		}
+	case *sdl.KeyboardEvent:
+		switch e.Keysym.Sym {
+		case sdl.K_f:
+			w.view.fit()
+			return true
+		case sdl.K_g:
+			w.view.grid = !w.view.grid
+			return true
+		case sdl.K_h:
+			w.hud.visible = !w.hud.visible
+			return true
+		case sdl.K_c:
+			w.palette = (w.palette + 1) % len(palettes)
+			w.hud.palette = palettes[w.palette].name
+			return true
+		case sdl.K_t:
+			w.trails = !w.trails
+			w.hud.trails = w.trails
+			return true
+		}
+	}
+	return false
+}
+
+// EditEvent turns clicks and drags with the left button into edits of the world. A click toggles the cell under the
+// pointer, and dragging sets each cell the pointer passes over the same way. It reports whether there is an edit to send.
+func (w *Window) EditEvent(event sdl.Event) (gol.EditCells, bool) {
+	switch e := event.(type) {
+	case *sdl.MouseButtonEvent:
+		if e.Button != sdl.BUTTON_LEFT {
+			break
+		}
+		if e.State != sdl.PRESSED {
+			w.painting = false
+			break
+		}
+		cell := w.view.cellAt(int(e.X), int(e.Y))
+		if cell.X < 0 || cell.Y < 0 || cell.X >= int(w.Width) || cell.Y >= int(w.Height) {
+			break
+		}
+		w.painting = true
+		w.paintAlive = !w.state.alive[cell.Y*int(w.Width)+cell.X]
+		w.lastCell = cell
+		return gol.EditCells{Cells: []util.Cell{cell}, Alive: w.paintAlive}, true
+	case *sdl.MouseMotionEvent:
+		cell := w.view.cellAt(int(e.X), int(e.Y))
+		if !w.painting || cell == w.lastCell {
+			break
+		}
+		//Cells off the edge of the world are left for the engine to ignore
+		cells := cellsBetween(w.lastCell, cell)
+		w.lastCell = cell
+		return gol.EditCells{Cells: cells, Alive: w.paintAlive}, true
+	}
+	return gol.EditCells{}, false
+}
+
+// AgeCells ages every cell by a turn. It should be called once for each TurnComplete.
+func (w *Window) AgeCells() {
+	w.state.turnComplete()
+}
+
 func (w *Window) SetPixel(x, y int) {
-	width := int(w.Width)
-	w.pixels[4*(y*width+x)+0] = 0xFF
-	w.pixels[4*(y*width+x)+1] = 0xFF
-	w.pixels[4*(y*width+x)+2] = 0xFF
-	w.pixels[4*(y*width+x)+3] = 0xFF
+	w.state.set(x, y, true)
 }
 
 func (w *Window) FlipPixel(x, y int) {
@@ -80,25 +196,32 @@ func (w *Window) FlipPixel(x, y int) {
 		panic(fmt.Sprintf("CellFlipped event at (%d, %d) is outside the bounds of the window.", x, y))
 	}
 
-	width := int(w.Width)
-	w.pixels[4*(y*width+x)+0] = ^w.pixels[4*(y*width+x)+0]
-	w.pixels[4*(y*width+x)+1] = ^w.pixels[4*(y*width+x)+1]
-	w.pixels[4*(y*width+x)+2] = ^w.pixels[4*(y*width+x)+2]
-	w.pixels[4*(y*width+x)+3] = ^w.pixels[4*(y*width+x)+3]
+	w.state.set(x, y, !w.state.alive[y*int(w.Width)+x])
 }
 
 func (w *Window) CountPixels() int {
-	count := 0
-	for i := 0; i < int(w.Width) * int(w.Height) * 4; i += 4 {
-		if w.pixels[i] == 0xFF {
-			count++
-		}
+	return w.state.count
+}
+
+// CheckCount compares the number of alive cells the window shows with the engine's count after completedTurns,
+// reporting whether they differ, as a missed or repeated CellFlipped leaves the window wrong until it is resynced.
+// The count is only compared when the window holds that turn and nothing has changed since.
+func (w *Window) CheckCount(completedTurns, count int) bool {
+	if !w.state.settled || completedTurns != w.hud.turn || w.state.count == count {
+		return false
 	}
-	return count
+	sdlLog.Debug("The window's count differs from the engine's", "turn", completedTurns, "shown", w.state.count,
+		"alive", count)
+	return true
 }
 
-func (w *Window) ClearPixels() {
-	for i := range w.pixels {
-		w.pixels[i] = 0
+// Resync makes the window show exactly the alive cells given, warning if it was showing anything else.
+func (w *Window) Resync(completedTurns int, alive []util.Cell) {
+	if changed := w.state.reset(alive); changed > 0 {
+		sdlLog.Warn("The window was out of step with the engine", "turn", completedTurns, "cells", changed)
 	}
 }
+
+func (w *Window) ClearPixels() {
+	w.state.clear()
+}
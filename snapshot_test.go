@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestSnapshot runs the 16x16 image for 100 turns with a snapshot every 50 turns, keeping only the last one,
+// and checks that the snapshot of turn 50 was removed and the snapshot of turn 100 holds the expected world,
+// then that saving a snapshot every turn saves every one of them.
+func TestSnapshot(t *testing.T) {
+	_ = os.Remove("out/snapshot-16x16x50.pgm")
+	_ = os.Remove("out/snapshot-16x16x100.pgm")
+
+	p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 16, ImageHeight: 16, SnapshotEvery: 50, SnapshotKeep: 1}
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	for range events {
+	}
+
+	if _, err := os.Stat("out/snapshot-16x16x50.pgm"); !os.IsNotExist(err) {
+		t.Errorf("Expected the snapshot of turn 50 to be removed, got %v", err)
+	}
+	cells := readAliveCells("out/snapshot-16x16x100.pgm", 16, 16)
+	assertEqualBoard(t, cells, readAliveCells("check/images/16x16x100.pgm", 16, 16), p)
+
+	//Saving every turn keeps up with the game without dropping any, however far the writer falls behind
+	for turn := 1; turn <= 200; turn++ {
+		_ = os.Remove(fmt.Sprintf("out/snapshot-16x16x%v.pgm", turn))
+	}
+	p = gol.Params{Turns: 200, Threads: 4, ImageWidth: 16, ImageHeight: 16, SnapshotEvery: 1}
+	events = make(chan gol.Event, 1000)
+	go gol.Run(p, events, nil)
+	for range events {
+	}
+	for turn := 1; turn <= 200; turn++ {
+		path := fmt.Sprintf("out/snapshot-16x16x%v.pgm", turn)
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected a snapshot of turn %v, got %v", turn, err)
+		}
+		_ = os.Remove(path)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestStamp composes an empty 16x16 world from a file of glider stamps, one rotated and one reflected,
+// then rubs the first glider out with an xor stamp and checks the world before any turns are run.
+func TestStamp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stamp")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+
+	glider := util.Pattern{
+		Width:  3,
+		Height: 3,
+		Alive:  []util.Cell{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}},
+	}
+	file, err := os.Create(filepath.Join(dir, "glider.rle"))
+	util.Check(err)
+	util.Check(util.WriteRLE(file, glider))
+	util.Check(file.Close())
+
+	composition := filepath.Join(dir, "gliders.txt")
+	util.Check(ioutil.WriteFile(composition, []byte("# Three gliders\nglider.rle 0,0\nglider.rle 8,8 rotate=180\n\nglider.rle 0,8 flip or\n"), 0644))
+	stamps, err := util.ReadStampFile(composition)
+	util.Check(err)
+	rubOut, err := util.ParseStamp(filepath.Join(dir, "glider.rle") + " 0,0 xor")
+	util.Check(err)
+	stamps = append(stamps, rubOut)
+
+	p := gol.Params{Turns: 0, Threads: 1, ImageWidth: 16, ImageHeight: 16, Stamps: stamps}
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	var cells []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.FinalTurnComplete:
+			cells = e.Alive
+		}
+	}
+
+	expected := []util.Cell{
+		//Rotated 180 degrees at (8, 8)
+		{X: 9, Y: 10}, {X: 8, Y: 9}, {X: 10, Y: 8}, {X: 9, Y: 8}, {X: 8, Y: 8},
+		//Reflected at (0, 8)
+		{X: 1, Y: 8}, {X: 0, Y: 9}, {X: 2, Y: 10}, {X: 1, Y: 10}, {X: 0, Y: 10},
+	}
+	assertEqualBoard(t, cells, expected, p)
+}
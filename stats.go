@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// writeStats passes every event from in through to out, writing each Stats event as a row of a CSV file.
+// The columns follow check/alive/*.csv, so completed_turns and alive_cells come first.
+func writeStats(filename string, p gol.Params, in <-chan gol.Event, out chan<- gol.Event) {
+	file, err := os.Create(filename)
+	util.Check(err)
+	defer file.Close()
+	defer close(out)
+
+	writer := csv.NewWriter(file)
+	header := []string{"completed_turns", "alive_cells", "births", "deaths", "changed_cells",
+		"min_x", "min_y", "max_x", "max_y"}
+	for i := 0; i < p.Threads; i++ {
+		header = append(header, fmt.Sprintf("strip_density_%d", i))
+	}
+	util.Check(writer.Write(header))
+
+	for event := range in {
+		if stats, ok := event.(gol.Stats); ok {
+			row := []string{
+				strconv.Itoa(stats.CompletedTurns),
+				strconv.Itoa(stats.Population),
+				strconv.Itoa(stats.Births),
+				strconv.Itoa(stats.Deaths),
+				strconv.Itoa(stats.ChangedCells),
+				strconv.Itoa(stats.BoundingBox.Min.X),
+				strconv.Itoa(stats.BoundingBox.Min.Y),
+				strconv.Itoa(stats.BoundingBox.Max.X),
+				strconv.Itoa(stats.BoundingBox.Max.Y),
+			}
+			for _, density := range stats.StripDensity {
+				row = append(row, strconv.FormatFloat(density, 'f', 6, 64))
+			}
+			util.Check(writer.Write(row))
+			//Flush every row so the file is usable even if the run is cut short
+			writer.Flush()
+			util.Check(writer.Error())
+		}
+		out <- event
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestStats checks the Stats events of a 512x512 image on 100 turns against the counts in check/alive.
+func TestStats(t *testing.T) {
+	p := gol.Params{
+		Turns:       100,
+		Threads:     8,
+		ImageWidth:  512,
+		ImageHeight: 512,
+		ReportStats: true,
+	}
+	alive := readAliveCounts(p.ImageWidth, p.ImageHeight)
+	testName := fmt.Sprintf("%dx%dx%d-%d", p.ImageWidth, p.ImageHeight, p.Turns, p.Threads)
+	t.Run(testName, func(t *testing.T) {
+		events := make(chan gol.Event)
+		go gol.Run(p, events, nil)
+		turns := 0
+		previous := -1
+		for event := range events {
+			switch e := event.(type) {
+			case gol.Stats:
+				turns++
+				if e.CompletedTurns != turns {
+					t.Fatalf("Expected Stats for turn %v, got turn %v", turns, e.CompletedTurns)
+				}
+				if e.Population != alive[e.CompletedTurns] {
+					t.Fatalf("At turn %v expected %v alive cells, got %v instead", e.CompletedTurns, alive[e.CompletedTurns], e.Population)
+				}
+				if e.ChangedCells != e.Births+e.Deaths {
+					t.Fatalf("At turn %v births %v and deaths %v do not add up to %v changed cells", e.CompletedTurns, e.Births, e.Deaths, e.ChangedCells)
+				}
+				if previous >= 0 && previous+e.Births-e.Deaths != e.Population {
+					t.Fatalf("At turn %v population %v does not follow from %v births and %v deaths", e.CompletedTurns, e.Population, e.Births, e.Deaths)
+				}
+				if len(e.StripDensity) != p.Threads {
+					t.Fatalf("Expected %v strip densities, got %v", p.Threads, len(e.StripDensity))
+				}
+				previous = e.Population
+			}
+		}
+		if turns != p.Turns {
+			t.Fatalf("Expected %v Stats events, got %v", p.Turns, turns)
+		}
+	})
+}
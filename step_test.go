@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestStep pauses the 16x16 image, presses n three times and checks that exactly three more turns are run.
+// It then slows the game down to 10 turns per second with - and checks the speed is reported and kept to.
+func TestStep(t *testing.T) {
+	p := gol.Params{Turns: 100000000, Threads: 4, ImageWidth: 16, ImageHeight: 16}
+	events := make(chan gol.Event, 1000)
+	commands := make(chan gol.Command, 10)
+	go gol.RunCommands(p, events, commands)
+
+	//Gives the last turn completed once no events have arrived for a while, keeping track of the speed reported
+	lastTurn := -1
+	rate := 0
+	settle := func() int {
+		for {
+			select {
+			case event := <-events:
+				switch e := event.(type) {
+				case gol.TurnComplete:
+					lastTurn = e.CompletedTurns
+				case gol.RateChanged:
+					rate = e.TurnsPerSecond
+				}
+			case <-time.After(300 * time.Millisecond):
+				return lastTurn
+			}
+		}
+	}
+
+	commands <- gol.KeyPress('p')
+	paused := settle()
+	for i := 0; i < 3; i++ {
+		commands <- gol.KeyPress('n')
+	}
+	if stepped := settle(); stepped != paused+3 {
+		t.Errorf("Expected 3 steps from turn %v to turn %v, got to turn %v", paused, paused+3, stepped)
+	}
+
+	for i := 0; i < 7; i++ {
+		commands <- gol.KeyPress('-')
+	}
+	commands <- gol.KeyPress('p')
+	start := lastTurn
+	deadline := time.After(time.Second)
+	for waiting := true; waiting; {
+		select {
+		case event := <-events:
+			switch e := event.(type) {
+			case gol.TurnComplete:
+				lastTurn = e.CompletedTurns
+			case gol.RateChanged:
+				rate = e.TurnsPerSecond
+			}
+		case <-deadline:
+			waiting = false
+		}
+	}
+	if rate != 10 {
+		t.Errorf("Expected the speed to be 10 turns per second, got %v", rate)
+	}
+	if ran := lastTurn - start; ran < 5 || ran > 15 {
+		t.Errorf("Expected about 10 turns in a second at 10 turns per second, got %v", ran)
+	}
+	commands <- gol.KeyPress('q')
+	for range events {
+	}
+}
@@ -0,0 +1,71 @@
+package term
+
+import (
+	"os"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// frameTime is the shortest time between frames, so that fast runs don't flood the terminal.
+const frameTime = time.Second / 20
+
+// Run draws the game in the terminal until it finishes, sending p, s, q, k, n, + and - key presses on to the engine as
+// commands, and [ and ] as Seeks.
+// braille draws 2x4 cells per character rather than 1x2 with half blocks.
+func Run(p gol.Params, events <-chan gol.Event, commands chan<- gol.Command, braille bool) {
+	s := NewScreen(p.ImageWidth, p.ImageHeight, braille)
+	defer s.Destroy()
+	go readKeys(commands)
+
+	var turn = 0
+	var lastFrame time.Time
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			s.FlipCell(e.Cell.X, e.Cell.Y)
+		case gol.TurnComplete:
+			turn = e.CompletedTurns
+			if time.Since(lastFrame) >= frameTime {
+				s.Render(turn)
+				lastFrame = time.Now()
+			}
+		case gol.RateChanged:
+			s.SetStatus(e.String())
+			s.Render(turn)
+		case gol.CellsEdited:
+			s.Render(turn)
+		case gol.StateChange:
+			s.SetStatus(e.NewState.String())
+			s.Render(e.CompletedTurns)
+		case gol.ImageOutputComplete:
+			s.SetStatus("Saved " + e.Filename)
+			s.Render(turn)
+		case gol.FinalTurnComplete:
+			s.Render(e.CompletedTurns)
+			return
+		}
+	}
+}
+
+//Helper function of Run. Passes on the keys the engine understands as they are typed.
+func readKeys(commands chan<- gol.Command) {
+	buffer := make([]byte, 1)
+	for {
+		if _, err := os.Stdin.Read(buffer); err != nil {
+			return
+		}
+		switch buffer[0] {
+		case 'p', 's', 'q', 'k', 'n', '+', '-':
+			commands <- gol.KeyPress(buffer[0])
+		case '=':
+			//+ without shift
+			commands <- gol.KeyPress('+')
+		case '[':
+			//[ and ] move a replay back and forward
+			commands <- gol.Seek(-gol.SeekStep)
+		case ']':
+			commands <- gol.Seek(gol.SeekStep)
+		}
+	}
+}
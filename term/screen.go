@@ -0,0 +1,173 @@
+package term
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/logging"
+)
+
+var termLog = logging.Component("term")
+
+// Screen draws the world in a terminal with ANSI escape codes.
+// Each character shows a block of cells: 1x2 with half blocks or 2x4 with braille dots, at 1:1 scale.
+// Worlds too big for the terminal are scaled down, and a dot is lit if any cell it covers is alive.
+type Screen struct {
+	Width, Height int
+	cells         [][]bool
+	alive         int
+	braille       bool
+	// scale is the width and height in cells of each half block or braille dot.
+	scale  int
+	out    *bufio.Writer
+	saved  string
+	status string
+}
+
+// NewScreen clears the terminal, hides the cursor and works out how far a width x height world must be scaled down.
+func NewScreen(width, height int, braille bool) *Screen {
+	cols, rows := terminalSize()
+	saved, err := makeRaw()
+	if err != nil {
+		termLog.Warn("Keys won't work until Enter is pressed, as the terminal could not be put in raw mode", "error", err)
+	}
+
+	scale := scaleToFit(width, height, cols, rows, braille)
+
+	cells := make([][]bool, height)
+	for i := range cells {
+		cells[i] = make([]bool, width)
+	}
+
+	screen := &Screen{
+		Width:   width,
+		Height:  height,
+		cells:   cells,
+		braille: braille,
+		scale:   scale,
+		out:     bufio.NewWriter(os.Stdout),
+		saved:   saved,
+	}
+	_, _ = screen.out.WriteString("\x1b[?25l\x1b[2J")
+	return screen
+}
+
+// Destroy shows the cursor again and puts the terminal back the way it was.
+func (s *Screen) Destroy() {
+	_, _ = s.out.WriteString("\x1b[?25h\n")
+	_ = s.out.Flush()
+	restore(s.saved)
+}
+
+// FlipCell changes a cell from dead to alive or alive to dead.
+func (s *Screen) FlipCell(x, y int) {
+	s.cells[y][x] = !s.cells[y][x]
+	if s.cells[y][x] {
+		s.alive++
+	} else {
+		s.alive--
+	}
+}
+
+// SetStatus sets the message shown at the end of the status line.
+func (s *Screen) SetStatus(status string) {
+	s.status = status
+}
+
+// Render redraws the world and the status line for the given turn.
+func (s *Screen) Render(turn int) {
+	_, _ = s.out.WriteString("\x1b[H")
+	for _, line := range drawLines(s.cells, s.braille, s.scale) {
+		_, _ = s.out.WriteString(line)
+		_, _ = s.out.WriteString("\x1b[K\n")
+	}
+	_, _ = fmt.Fprintf(s.out, "\x1b[7m Turn %-10v Alive %-8v 1:%-3v %-12v\x1b[0m p pause  n step  +/- speed  s save  q quit\x1b[K",
+		turn, s.alive, s.scale, s.status)
+	_ = s.out.Flush()
+}
+
+//Helper function of NewScreen. The smallest scale at which a width x height world fits in the terminal,
+//leaving the bottom row for the status line
+func scaleToFit(width, height, cols, rows int, braille bool) int {
+	dotWidth, dotHeight := 1, 2
+	if braille {
+		dotWidth, dotHeight = 2, 4
+	}
+	scale := 1
+	for ceilDiv(width, dotWidth*scale) > cols || ceilDiv(height, dotHeight*scale) > rows-1 {
+		scale++
+	}
+	return scale
+}
+
+//Helper function of Render. Turns the world into lines of half block or braille characters,
+//with each dot covering a scale x scale block of cells
+func drawLines(cells [][]bool, braille bool, scale int) []string {
+	height := len(cells)
+	width := 0
+	if height > 0 {
+		width = len(cells[0])
+	}
+
+	//Whether any cell in the block of the dot at x, y is alive
+	lit := func(x, y int) bool {
+		for dy := 0; dy < scale; dy++ {
+			for dx := 0; dx < scale; dx++ {
+				cellX, cellY := x*scale+dx, y*scale+dy
+				if cellX < width && cellY < height && cells[cellY][cellX] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	dotsWide, dotsHigh := ceilDiv(width, scale), ceilDiv(height, scale)
+	var lines []string
+	var line strings.Builder
+	if braille {
+		//Braille dots are numbered down the left column, then down the right, then the bottom row
+		bits := [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+		for y := 0; y < dotsHigh; y += 4 {
+			line.Reset()
+			for x := 0; x < dotsWide; x += 2 {
+				char := rune(0x2800)
+				for dy := 0; dy < 4; dy++ {
+					for dx := 0; dx < 2; dx++ {
+						if lit(x+dx, y+dy) {
+							char |= bits[dy][dx]
+						}
+					}
+				}
+				line.WriteRune(char)
+			}
+			lines = append(lines, line.String())
+		}
+		return lines
+	}
+
+	for y := 0; y < dotsHigh; y += 2 {
+		line.Reset()
+		for x := 0; x < dotsWide; x++ {
+			top, bottom := lit(x, y), lit(x, y+1)
+			switch {
+			case top && bottom:
+				line.WriteRune('█')
+			case top:
+				line.WriteRune('▀')
+			case bottom:
+				line.WriteRune('▄')
+			default:
+				line.WriteRune(' ')
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,103 @@
+package term
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestScaleToFit checks the scale worlds are drawn at, with half blocks and braille, in terminals big and small.
+func TestScaleToFit(t *testing.T) {
+	tests := []struct {
+		width, height, cols, rows int
+		braille                   bool
+		scale                     int
+	}{
+		{16, 16, 80, 24, false, 1},
+		{80, 46, 80, 24, false, 1},
+		{81, 46, 80, 24, false, 2},
+		{80, 47, 80, 24, false, 2},
+		{512, 512, 80, 24, false, 12},
+		{160, 92, 80, 24, true, 1},
+		{161, 92, 80, 24, true, 2},
+		{160, 93, 80, 24, true, 2},
+		{512, 512, 80, 24, true, 6},
+		{1, 1, 1, 2, false, 1},
+	}
+	for _, test := range tests {
+		name := fmt.Sprintf("%vx%v-in-%vx%v-braille-%v", test.width, test.height, test.cols, test.rows, test.braille)
+		t.Run(name, func(t *testing.T) {
+			scale := scaleToFit(test.width, test.height, test.cols, test.rows, test.braille)
+			if scale != test.scale {
+				t.Errorf("Expected scale %v, got %v", test.scale, scale)
+			}
+		})
+	}
+}
+
+// TestDrawLines checks how cells are packed into half block and braille characters, at 1:1 and scaled down.
+func TestDrawLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    []string
+		braille bool
+		scale   int
+		lines   []string
+	}{
+		{"half-blocks", []string{"#.#.", "..##"}, false, 1, []string{"▀ █▄"}},
+		{"half-blocks-odd-height", []string{"#.", "..", ".#"}, false, 1, []string{"▀ ", " ▀"}},
+		{"half-blocks-scaled", []string{"#...", "....", "...#", "...."}, false, 2, []string{"▀▄"}},
+		{"braille-empty", []string{"..", "..", "..", ".."}, true, 1, []string{"⠀"}},
+		{"braille-full", []string{"##", "##", "##", "##"}, true, 1, []string{"⣿"}},
+		{"braille-dots", []string{"#.", ".#", "#.", ".#"}, true, 1, []string{string(rune(0x2800 | 0x01 | 0x10 | 0x04 | 0x80))}},
+		{"braille-two-characters", []string{"..#", "...", "...", "..."}, true, 1, []string{"⠀⠁"}},
+		{"braille-scaled", []string{"....", ".#..", "....", "....", "....", "....", "....", "...#"}, true, 2,
+			[]string{string(rune(0x2800 | 0x01 | 0x80))}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := drawLines(cellsFrom(test.rows), test.braille, test.scale)
+			if strings.Join(lines, "\n") != strings.Join(test.lines, "\n") {
+				t.Errorf("Expected lines %q, got %q", test.lines, lines)
+			}
+		})
+	}
+}
+
+// TestRender draws a screen into a buffer and checks the world, the alive count, the scale and the status line.
+func TestRender(t *testing.T) {
+	var buffer bytes.Buffer
+	s := &Screen{Width: 4, Height: 2, cells: cellsFrom([]string{"....", "...."}), scale: 1, out: bufio.NewWriter(&buffer)}
+	s.FlipCell(0, 0)
+	s.FlipCell(1, 1)
+	s.FlipCell(2, 0)
+	s.FlipCell(2, 0)
+	s.SetStatus("Paused")
+	s.Render(7)
+
+	output := buffer.String()
+	if !strings.HasPrefix(output, "\x1b[H▀▄  \x1b[K\n") {
+		t.Errorf("Expected the world drawn from the top left, got %q", output)
+	}
+	status := "\x1b[7m Turn 7          Alive 2        1:1   Paused      \x1b[0m"
+	if !strings.Contains(output, status) {
+		t.Errorf("Expected the status line %q in %q", status, output)
+	}
+	if !strings.HasSuffix(output, "q quit\x1b[K") {
+		t.Errorf("Expected the keys at the end of the status line, got %q", output)
+	}
+}
+
+//Helper function of the term tests. Turns rows of # and . into cells.
+func cellsFrom(rows []string) [][]bool {
+	cells := make([][]bool, len(rows))
+	for y, row := range rows {
+		cells[y] = make([]bool, len(row))
+		for x, c := range row {
+			cells[y][x] = c == '#'
+		}
+	}
+	return cells
+}
@@ -0,0 +1,47 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+//Runs stty on the terminal attached to stdin
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+//Makes key presses arrive as soon as they are pressed, without being echoed, and returns the settings to restore.
+//Ctrl-C still interrupts the program.
+func makeRaw() (string, error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return "", err
+	}
+	_, err = stty("-icanon", "-echo", "min", "1")
+	return saved, err
+}
+
+//Puts back the terminal settings saved by makeRaw
+func restore(saved string) {
+	if saved != "" {
+		_, _ = stty(saved)
+	}
+}
+
+//Gives the number of columns and rows of the terminal, or 80x24 if it can't be found
+func terminalSize() (int, int) {
+	var cols, rows int
+	size, err := stty("size")
+	if err == nil {
+		_, err = fmt.Sscanf(size, "%d %d", &rows, &cols)
+	}
+	if err != nil || cols <= 0 || rows <= 1 {
+		return 80, 24
+	}
+	return cols, rows
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// timingJSON is a gol.Timing as it is exported, in seconds.
+type timingJSON struct {
+	MinSeconds  float64 `json:"min_seconds"`
+	MeanSeconds float64 `json:"mean_seconds"`
+	MaxSeconds  float64 `json:"max_seconds"`
+	Count       int     `json:"count"`
+}
+
+// timingReportJSON is a gol.TimingReport as it is exported.
+type timingReportJSON struct {
+	CompletedTurns int          `json:"completed_turns"`
+	Turn           timingJSON   `json:"turn"`
+	Strips         timingJSON   `json:"strips"`
+	Compute        timingJSON   `json:"compute"`
+	Merge          timingJSON   `json:"merge"`
+	Events         timingJSON   `json:"events"`
+	Workers        []timingJSON `json:"workers"`
+	Imbalance      float64      `json:"imbalance"`
+}
+
+// reportTimings passes every event from in through to out, printing the TimingReport at the end of the run to
+// stdout as a table when print is set, and writing it to jsonFile as JSON when one is given.
+func reportTimings(print bool, jsonFile string, in <-chan gol.Event, out chan<- gol.Event) {
+	defer close(out)
+	for event := range in {
+		if report, ok := event.(gol.TimingReport); ok {
+			if print {
+				writeTimingTable(os.Stdout, report)
+			}
+			if jsonFile != "" {
+				encoded, err := json.MarshalIndent(timingsToJSON(report), "", "  ")
+				util.Check(err)
+				util.Check(ioutil.WriteFile(jsonFile, append(encoded, '\n'), 0644))
+				mainLog.Info("Timing report output done", "turn", report.CompletedTurns, "file", jsonFile)
+			}
+		}
+		out <- event
+	}
+}
+
+//Writes the report with a row for each phase and worker
+func writeTimingTable(w io.Writer, report gol.TimingReport) {
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	_, _ = fmt.Fprintf(w, "Timings of %v turns:\n", report.Turn.Count)
+	_, _ = fmt.Fprintln(table, "\tmin\tmean\tmax\t")
+	names := []string{"turn", "strips", "compute", "merge", "events"}
+	timings := []gol.Timing{report.Turn, report.Strips, report.Compute, report.Merge, report.Events}
+	for worker, timing := range report.Workers {
+		names = append(names, "worker "+strconv.Itoa(worker))
+		timings = append(timings, timing)
+	}
+	for i, timing := range timings {
+		if timing.Count == 0 {
+			_, _ = fmt.Fprintf(table, "%v\t-\t-\t-\t\n", names[i])
+			continue
+		}
+		_, _ = fmt.Fprintf(table, "%v\t%v\t%v\t%v\t\n", names[i], timing.Min.Round(time.Microsecond),
+			timing.Mean.Round(time.Microsecond), timing.Max.Round(time.Microsecond))
+	}
+	util.Check(table.Flush())
+	_, _ = fmt.Fprintf(w, "Imbalance (slowest worker over the mean): %.3f\n", report.Imbalance)
+}
+
+func timingsToJSON(report gol.TimingReport) timingReportJSON {
+	convert := func(timing gol.Timing) timingJSON {
+		return timingJSON{MinSeconds: timing.Min.Seconds(), MeanSeconds: timing.Mean.Seconds(),
+			MaxSeconds: timing.Max.Seconds(), Count: timing.Count}
+	}
+	exported := timingReportJSON{
+		CompletedTurns: report.CompletedTurns,
+		Turn:           convert(report.Turn),
+		Strips:         convert(report.Strips),
+		Compute:        convert(report.Compute),
+		Merge:          convert(report.Merge),
+		Events:         convert(report.Events),
+		Imbalance:      report.Imbalance,
+	}
+	for _, timing := range report.Workers {
+		exported.Workers = append(exported.Workers, convert(timing))
+	}
+	return exported
+}
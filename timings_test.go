@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestTimings runs the 64x64 image for 20 turns with 4 threads and then 1, checking that the timing report covers
+// every turn, phase and worker, and that it can be printed and exported.
+func TestTimings(t *testing.T) {
+	report := runTimed(gol.Params{Turns: 20, Threads: 4, ImageWidth: 64, ImageHeight: 64, ReportTimings: true})
+	counts := map[string][2]int{
+		"turn": {report.Turn.Count, 20}, "strips": {report.Strips.Count, 80}, "compute": {report.Compute.Count, 80},
+		"merge": {report.Merge.Count, 20}, "events": {report.Events.Count, 20}, "workers": {len(report.Workers), 4},
+	}
+	for name, count := range counts {
+		if count[0] != count[1] {
+			t.Errorf("Expected %v %v timings, got %v", count[1], name, count[0])
+		}
+	}
+	for _, timing := range append(report.Workers, report.Turn, report.Compute) {
+		if timing.Count > 0 && (timing.Min > timing.Mean || timing.Mean > timing.Max || timing.Max <= 0) {
+			t.Errorf("Expected 0 < min <= mean <= max, got %+v", timing)
+		}
+	}
+	if report.Imbalance < 1 {
+		t.Errorf("Expected the slowest worker to take at least the mean time, got an imbalance of %v", report.Imbalance)
+	}
+
+	var table bytes.Buffer
+	writeTimingTable(&table, report)
+	for _, row := range []string{"Timings of 20 turns:", "merge", "worker 3", "Imbalance"} {
+		if !strings.Contains(table.String(), row) {
+			t.Errorf("Expected %q in the table, got\n%v", row, table.String())
+		}
+	}
+	encoded, err := json.Marshal(timingsToJSON(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported timingReportJSON
+	if err := json.Unmarshal(encoded, &exported); err != nil || exported.CompletedTurns != 20 ||
+		len(exported.Workers) != 4 || exported.Turn.MeanSeconds != report.Turn.Mean.Seconds() {
+		t.Errorf("Expected the JSON to hold the report, got %s", encoded)
+	}
+
+	single := runTimed(gol.Params{Turns: 20, Threads: 1, ImageWidth: 64, ImageHeight: 64, ReportTimings: true})
+	if single.Strips.Count != 0 || single.Merge.Count != 0 || single.Compute.Count != 20 || single.Imbalance != 1 {
+		t.Errorf("Expected one thread to be timed without strips, got %+v", single)
+	}
+}
+
+//Runs the game and gives its timing report
+func runTimed(p gol.Params) gol.TimingReport {
+	events := make(chan gol.Event, 1000)
+	go gol.Run(p, events, nil)
+	var report gol.TimingReport
+	for event := range events {
+		if timings, ok := event.(gol.TimingReport); ok {
+			report = timings
+		}
+	}
+	return report
+}
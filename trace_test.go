@@ -9,6 +9,7 @@ import (
 )
 
 // TestTrace is a special test to be used to generate traces - not a real test
+// Each turn shows as a task in go tool trace, with regions for making the strips, computing, merging and events.
 func TestTrace(t *testing.T) {
 	traceParams := gol.Params{
 		Turns:       10,
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadCells parses a plaintext pattern, where ! starts a comment line and each other line
+// is a row of . for dead cells and O for alive ones, e.g.
+//
+//	!Name: Glider
+//	.O.
+//	..O
+//	OOO
+func ReadCells(r io.Reader) (Pattern, error) {
+	var pattern Pattern
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	y := 0
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(line, "!Rule:") {
+			pattern.Rule = strings.TrimSpace(line[len("!Rule:"):])
+			continue
+		}
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		for x, char := range line {
+			switch char {
+			case 'O', 'o', '*':
+				pattern.Alive = append(pattern.Alive, Cell{X: x, Y: y})
+			case '.':
+			default:
+				return pattern, fmt.Errorf("cells: unexpected %q on row %d", char, y)
+			}
+		}
+		if len(line) > pattern.Width {
+			pattern.Width = len(line)
+		}
+		y++
+	}
+	if err := scanner.Err(); err != nil {
+		return pattern, err
+	}
+
+	pattern.Height = y
+	return pattern, nil
+}
+
+// WriteCells writes the pattern in plaintext.
+// Every row is written in full so that the pattern keeps its size when it is read back in.
+func WriteCells(w io.Writer, pattern Pattern) error {
+	writer := bufio.NewWriter(w)
+	if pattern.Rule != "" {
+		_, _ = writer.WriteString("!Rule: " + pattern.Rule + "\n")
+	}
+
+	grid := make([][]byte, pattern.Height)
+	for i := range grid {
+		grid[i] = []byte(strings.Repeat(".", pattern.Width))
+	}
+	for _, cell := range pattern.Alive {
+		grid[cell.Y][cell.X] = 'O'
+	}
+	for _, row := range grid {
+		_, _ = writer.Write(append(row, '\n'))
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,122 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The pattern file formats we can read and write.
+// FormatPGM covers PBM bitmaps too, though we only ever write PGM.
+// PNG is only ever written, as a picture of the world to share.
+const (
+	FormatPGM     = "pgm"
+	FormatRLE     = "rle"
+	FormatCells   = "cells"
+	FormatLife106 = "life106"
+	FormatPNG     = "png"
+)
+
+// Extension gives the file extension, including the dot, that files of the format are saved with.
+func Extension(format string) string {
+	if format == FormatLife106 {
+		return ".lif"
+	}
+	return "." + format
+}
+
+// DetectFormat works out the format of a pattern file from its extension.
+// If the extension isn't one we know, the first line of the file decides.
+func DetectFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pgm", ".pbm":
+		return FormatPGM, nil
+	case ".rle":
+		return FormatRLE, nil
+	case ".cells":
+		return FormatCells, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+
+	switch {
+	case len(line) >= 2 && line[0] == 'P' && strings.ContainsRune("1245", rune(line[1])):
+		return FormatPGM, nil
+	case strings.HasPrefix(line, life106Header):
+		return FormatLife106, nil
+	case strings.HasPrefix(line, "!") || (line != "" && strings.Trim(line, ".O") == ""):
+		return FormatCells, nil
+	case strings.HasPrefix(line, "#") || strings.HasPrefix(line, "x"):
+		return FormatRLE, nil
+	}
+	return "", fmt.Errorf("%v is not a pattern file we recognise", path)
+}
+
+// ReadPatternFile reads a pattern file in any format but PGM, which holds a whole world rather than a pattern.
+func ReadPatternFile(path string) (Pattern, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return Pattern{}, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return Pattern{}, err
+	}
+	defer file.Close()
+
+	switch format {
+	case FormatRLE:
+		return ReadRLE(file)
+	case FormatCells:
+		return ReadCells(file)
+	case FormatLife106:
+		return ReadLife106(file)
+	}
+	return Pattern{}, fmt.Errorf("%v is a %v file, not a pattern", path, format)
+}
+
+// FileDimensions gives the width and height of the world in a PGM file, or of the pattern in any other format.
+// Only the header of a PGM file is read.
+func FileDimensions(path string) (int, int, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	if format != FormatPGM {
+		pattern, err := ReadPatternFile(path)
+		return pattern.Width, pattern.Height, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+	header, err := ReadPNMHeader(bufio.NewReader(file))
+	return header.Width, header.Height, err
+}
+
+// WritePattern writes the pattern in any format but PGM.
+func WritePattern(w io.Writer, pattern Pattern, format string) error {
+	switch format {
+	case FormatRLE:
+		return WriteRLE(w, pattern)
+	case FormatCells:
+		return WriteCells(w, pattern)
+	case FormatLife106:
+		return WriteLife106(w, pattern)
+	}
+	return fmt.Errorf("can't write a pattern as %v", format)
+}
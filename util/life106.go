@@ -0,0 +1,76 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// life106Header is the first line of every Life 1.06 file.
+const life106Header = "#Life 1.06"
+
+// ReadLife106 parses a Life 1.06 pattern, which lists the x y coordinates of each alive cell, e.g.
+//
+//	#Life 1.06
+//	1 0
+//	2 1
+//	0 2
+//
+// Coordinates may be negative, so the cells are moved to start at (0, 0).
+func ReadLife106(r io.Reader) (Pattern, error) {
+	var pattern Pattern
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var cell Cell
+		if _, err := fmt.Sscanf(line, "%d %d", &cell.X, &cell.Y); err != nil {
+			return pattern, fmt.Errorf("life 1.06: malformed coordinates %q", line)
+		}
+		pattern.Alive = append(pattern.Alive, cell)
+	}
+	if err := scanner.Err(); err != nil {
+		return pattern, err
+	}
+	if len(pattern.Alive) == 0 {
+		return pattern, nil
+	}
+
+	min, max := pattern.Alive[0], pattern.Alive[0]
+	for _, cell := range pattern.Alive {
+		if cell.X < min.X {
+			min.X = cell.X
+		}
+		if cell.Y < min.Y {
+			min.Y = cell.Y
+		}
+		if cell.X > max.X {
+			max.X = cell.X
+		}
+		if cell.Y > max.Y {
+			max.Y = cell.Y
+		}
+	}
+	for i := range pattern.Alive {
+		pattern.Alive[i].X -= min.X
+		pattern.Alive[i].Y -= min.Y
+	}
+	pattern.Width = max.X - min.X + 1
+	pattern.Height = max.Y - min.Y + 1
+	return pattern, nil
+}
+
+// WriteLife106 writes the alive cells of the pattern as Life 1.06 coordinates.
+// These are the same cells the engine reports in FinalTurnComplete.
+func WriteLife106(w io.Writer, pattern Pattern) error {
+	writer := bufio.NewWriter(w)
+	_, _ = writer.WriteString(life106Header + "\n")
+	for _, cell := range pattern.Alive {
+		_, _ = fmt.Fprintf(writer, "%d %d\n", cell.X, cell.Y)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,84 @@
+package util
+
+// Pattern is a set of alive cells loaded from a pattern file.
+// Cells are relative to the top left corner of the pattern's Width x Height bounding box.
+type Pattern struct {
+	Width, Height int
+	// Rule is the rule named by the file, such as "B3/S23". It is empty if the file doesn't name one.
+	Rule  string
+	Alive []Cell
+}
+
+// Centre returns the offset that places the pattern in the middle of a width x height world.
+func (pattern Pattern) Centre(width, height int) Cell {
+	return Cell{X: (width - pattern.Width) / 2, Y: (height - pattern.Height) / 2}
+}
+
+// Place sets the pattern's cells to 0xFF in world, with its top left corner at offset.
+// Cells that fall outside the world wrap around to the other side, as they do in the game.
+func (pattern Pattern) Place(world [][]byte, offset Cell) {
+	height := len(world)
+	if height == 0 {
+		return
+	}
+	width := len(world[0])
+	for _, cell := range pattern.Alive {
+		x := ((cell.X+offset.X)%width + width) % width
+		y := ((cell.Y+offset.Y)%height + height) % height
+		world[y][x] = 0xFF
+	}
+}
+
+// PatternFromWorld collects the alive cells of a world into a Pattern the size of the whole world.
+func PatternFromWorld(world [][]byte, rule string) Pattern {
+	pattern := Pattern{Height: len(world), Rule: rule}
+	if pattern.Height > 0 {
+		pattern.Width = len(world[0])
+	}
+	for y, row := range world {
+		for x, tile := range row {
+			if tile != 0 {
+				pattern.Alive = append(pattern.Alive, Cell{X: x, Y: y})
+			}
+		}
+	}
+	return pattern
+}
+
+// Toggle flips the pattern's cells in world, with its top left corner at offset, so that stamping the same
+// pattern twice in the same place leaves the world as it was. Cells wrap around the edges as they do in Place.
+func (pattern Pattern) Toggle(world [][]byte, offset Cell) {
+	height := len(world)
+	if height == 0 {
+		return
+	}
+	width := len(world[0])
+	for _, cell := range pattern.Alive {
+		x := ((cell.X+offset.X)%width + width) % width
+		y := ((cell.Y+offset.Y)%height + height) % height
+		world[y][x] ^= 0xFF
+	}
+}
+
+// Rotate returns the pattern turned clockwise by quarterTurns quarter turns.
+func (pattern Pattern) Rotate(quarterTurns int) Pattern {
+	rotated := pattern
+	for i := 0; i < ((quarterTurns%4)+4)%4; i++ {
+		alive := make([]Cell, len(rotated.Alive))
+		for j, cell := range rotated.Alive {
+			alive[j] = Cell{X: rotated.Height - 1 - cell.Y, Y: cell.X}
+		}
+		rotated = Pattern{Width: rotated.Height, Height: rotated.Width, Rule: rotated.Rule, Alive: alive}
+	}
+	return rotated
+}
+
+// Reflect returns the pattern mirrored left to right.
+func (pattern Pattern) Reflect() Pattern {
+	reflected := pattern
+	reflected.Alive = make([]Cell, len(pattern.Alive))
+	for i, cell := range pattern.Alive {
+		reflected.Alive[i] = Cell{X: pattern.Width - 1 - cell.X, Y: cell.Y}
+	}
+	return reflected
+}
@@ -0,0 +1,201 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DefaultThreshold is the fraction of the maximum value at or above which a grey pixel counts as alive.
+const DefaultThreshold = 0.5
+
+// PNMHeader describes a netpbm image.
+type PNMHeader struct {
+	// Magic is P1 or P4 for a bitmap (PBM) and P2 or P5 for a greymap (PGM).
+	Magic         string
+	Width, Height int
+	// MaxVal is the value of a white pixel. It is 1 for bitmaps.
+	MaxVal int
+}
+
+// ReadPNMHeader reads the header of a PBM or PGM image, leaving r at the start of the pixel data.
+// Comments from # to the end of the line may appear anywhere in the header.
+func ReadPNMHeader(r *bufio.Reader) (PNMHeader, error) {
+	var header PNMHeader
+	magic, err := readPNMToken(r)
+	if err != nil {
+		return header, err
+	}
+	switch magic {
+	case "P1", "P2", "P4", "P5":
+		header.Magic = magic
+	default:
+		return header, fmt.Errorf("pnm: %q is not a PBM or PGM magic number", magic)
+	}
+
+	fields := []*int{&header.Width, &header.Height}
+	header.MaxVal = 1
+	if header.Magic == "P2" || header.Magic == "P5" {
+		fields = append(fields, &header.MaxVal)
+	}
+	for _, field := range fields {
+		token, err := readPNMToken(r)
+		if err != nil {
+			return header, err
+		}
+		*field, err = strconv.Atoi(token)
+		if err != nil || *field < 0 {
+			return header, fmt.Errorf("pnm: malformed header value %q", token)
+		}
+	}
+	if header.MaxVal < 1 || header.MaxVal > 65535 {
+		return header, fmt.Errorf("pnm: maxval %d is out of range", header.MaxVal)
+	}
+	return header, nil
+}
+
+// ReadPNM reads a PBM or PGM image into a world of 0xFF for alive cells and 0 for dead ones.
+// Bitmaps are alive where a pixel is set (black). Greymaps are alive where a pixel is at least
+// threshold of the way from black to white. Both the ASCII and binary variants are understood,
+// as are 16-bit greymaps.
+func ReadPNM(r io.Reader, threshold float64) (PNMHeader, [][]byte, error) {
+	reader := bufio.NewReaderSize(r, 1<<16)
+	header, err := ReadPNMHeader(reader)
+	if err != nil {
+		return header, nil, err
+	}
+
+	world := make([][]byte, header.Height)
+	for i := range world {
+		world[i] = make([]byte, header.Width)
+	}
+
+	var liveFrom = threshold * float64(header.MaxVal)
+	for y := 0; y < header.Height; y++ {
+		switch header.Magic {
+		case "P4":
+			//Each row is packed 8 pixels to a byte, most significant bit first, and padded to a whole byte
+			row := make([]byte, (header.Width+7)/8)
+			if _, err := io.ReadFull(reader, row); err != nil {
+				return header, nil, fmt.Errorf("pnm: pixel data ends early: %v", err)
+			}
+			for x := 0; x < header.Width; x++ {
+				if row[x/8]&(0x80>>uint(x%8)) != 0 {
+					world[y][x] = 0xFF
+				}
+			}
+		case "P5":
+			//Binary greymaps are read a row at a time rather than a value at a time
+			bytesPerValue := 1
+			if header.MaxVal > 255 {
+				bytesPerValue = 2
+			}
+			row := make([]byte, header.Width*bytesPerValue)
+			if _, err := io.ReadFull(reader, row); err != nil {
+				return header, nil, fmt.Errorf("pnm: pixel data ends early: %v", err)
+			}
+			for x := 0; x < header.Width; x++ {
+				value := int(row[x*bytesPerValue])
+				if bytesPerValue == 2 {
+					//Values above 255 take two bytes, most significant first
+					value = value<<8 | int(row[x*2+1])
+				}
+				if float64(value) >= liveFrom {
+					world[y][x] = 0xFF
+				}
+			}
+		default:
+			for x := 0; x < header.Width; x++ {
+				value, err := readPNMValue(reader, header)
+				if err != nil {
+					return header, nil, err
+				}
+				if header.Magic == "P1" {
+					if value == 1 {
+						world[y][x] = 0xFF
+					}
+				} else if float64(value) >= liveFrom {
+					world[y][x] = 0xFF
+				}
+			}
+		}
+	}
+	return header, world, nil
+}
+
+//Reads the next pixel value of a P1 or P2 image
+func readPNMValue(r *bufio.Reader, header PNMHeader) (int, error) {
+	switch header.Magic {
+	case "P1":
+		//Bitmap digits don't need whitespace between them
+		if err := skipPNMSpace(r); err != nil {
+			return 0, fmt.Errorf("pnm: pixel data ends early: %v", err)
+		}
+		digit, err := r.ReadByte()
+		if err != nil || (digit != '0' && digit != '1') {
+			return 0, fmt.Errorf("pnm: malformed bitmap pixel")
+		}
+		return int(digit - '0'), nil
+	default:
+		token, err := readPNMToken(r)
+		if err != nil {
+			return 0, fmt.Errorf("pnm: pixel data ends early: %v", err)
+		}
+		value, err := strconv.Atoi(token)
+		if err != nil || value < 0 || value > header.MaxVal {
+			return 0, fmt.Errorf("pnm: malformed pixel value %q", token)
+		}
+		return value, nil
+	}
+}
+
+//Reads the next whitespace separated token, skipping comments.
+//Exactly one whitespace character after the token is consumed, which is where binary pixel data begins.
+func readPNMToken(r *bufio.Reader) (string, error) {
+	if err := skipPNMSpace(r); err != nil {
+		return "", err
+	}
+	var token []byte
+	for {
+		char, err := r.ReadByte()
+		if err == io.EOF && len(token) > 0 {
+			return string(token), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if isPNMSpace(char) {
+			return string(token), nil
+		}
+		if char == '#' {
+			//A comment straight after a token still ends it
+			_ = r.UnreadByte()
+			return string(token), nil
+		}
+		token = append(token, char)
+	}
+}
+
+//Skips whitespace and comments up to the start of the next token
+func skipPNMSpace(r *bufio.Reader) error {
+	for {
+		char, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if char == '#' {
+			if _, err := r.ReadString('\n'); err != nil {
+				return err
+			}
+			continue
+		}
+		if !isPNMSpace(char) {
+			return r.UnreadByte()
+		}
+	}
+}
+
+func isPNMSpace(char byte) bool {
+	return char == ' ' || char == '\t' || char == '\n' || char == '\r' || char == '\v' || char == '\f'
+}
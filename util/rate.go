@@ -0,0 +1,38 @@
+package util
+
+import "time"
+
+// TurnRates are the speeds, in turns per second, that ChangeRate steps through from slowest to fastest.
+// Zero means as fast as the workers can go.
+var TurnRates = []int{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 0}
+
+// ChangeRate gives the next speed on TurnRates after turnsPerSecond, or the one before it when faster is false.
+// A speed that isn't on TurnRates moves to the nearest one in that direction.
+func ChangeRate(turnsPerSecond int, faster bool) int {
+	position := len(TurnRates) - 1
+	for i, rate := range TurnRates {
+		if rate != 0 && turnsPerSecond != 0 && rate >= turnsPerSecond {
+			position = i
+			if rate > turnsPerSecond && faster {
+				//Between two speeds, the next one up is the one it is just below
+				position--
+			}
+			break
+		}
+	}
+	if faster && position < len(TurnRates)-1 {
+		position++
+	} else if !faster && position > 0 {
+		position--
+	}
+	return TurnRates[position]
+}
+
+// RateDelay gives how long after now the next turn is due, if the last one started at last and the game should run
+// at turnsPerSecond. It is zero or less once the turn is due, and always zero when turnsPerSecond is zero.
+func RateDelay(last time.Time, turnsPerSecond int, now time.Time) time.Duration {
+	if turnsPerSecond <= 0 {
+		return 0
+	}
+	return last.Add(time.Second / time.Duration(turnsPerSecond)).Sub(now)
+}
@@ -0,0 +1,177 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// rleLineLength is the longest line WriteRLE produces, as recommended by the format.
+const rleLineLength = 70
+
+// ReadRLE parses a run length encoded pattern, e.g.
+//
+//	#N Glider
+//	x = 3, y = 3, rule = B3/S23
+//	bo$2bo$3o!
+func ReadRLE(r io.Reader) (Pattern, error) {
+	var pattern Pattern
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	//Everything before the header line is a comment. Older files give the rule in a #r comment.
+	headerFound := false
+	for !headerFound && scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#r"):
+			pattern.Rule = strings.TrimSpace(line[2:])
+		case strings.HasPrefix(line, "#"):
+		default:
+			if err := parseRLEHeader(line, &pattern); err != nil {
+				return pattern, err
+			}
+			headerFound = true
+		}
+	}
+	if !headerFound {
+		return pattern, fmt.Errorf("rle: missing x = m, y = n header")
+	}
+
+	x, y, count := 0, 0, 0
+	for scanner.Scan() {
+		for _, char := range strings.TrimSpace(scanner.Text()) {
+			if char >= '0' && char <= '9' {
+				count = count*10 + int(char-'0')
+				continue
+			}
+			run := count
+			if run == 0 {
+				run = 1
+			}
+			count = 0
+
+			switch {
+			case char == '!':
+				return pattern, nil
+			case char == '$':
+				x = 0
+				y += run
+			case char == 'b' || char == '.':
+				x += run
+			case char == 'o' || (char >= 'A' && char <= 'X'):
+				if x+run > pattern.Width || y >= pattern.Height {
+					return pattern, fmt.Errorf("rle: pattern exceeds its x = %d, y = %d header",
+						pattern.Width, pattern.Height)
+				}
+				for i := 0; i < run; i++ {
+					pattern.Alive = append(pattern.Alive, Cell{X: x, Y: y})
+					x++
+				}
+			case char == ' ' || char == '\t':
+			default:
+				return pattern, fmt.Errorf("rle: unexpected %q in pattern", char)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return pattern, err
+	}
+	//The terminating ! is often missing from hand written files, so we accept what we have
+	return pattern, nil
+}
+
+//Parses the "x = m, y = n, rule = abc" line of an RLE file into pattern
+func parseRLEHeader(line string, pattern *Pattern) error {
+	width, height := -1, -1
+	for _, field := range strings.Split(line, ",") {
+		pair := strings.SplitN(field, "=", 2)
+		if len(pair) != 2 {
+			return fmt.Errorf("rle: malformed header %q", line)
+		}
+		key := strings.TrimSpace(pair[0])
+		value := strings.TrimSpace(pair[1])
+		var err error
+		switch key {
+		case "x":
+			width, err = strconv.Atoi(value)
+		case "y":
+			height, err = strconv.Atoi(value)
+		case "rule":
+			pattern.Rule = value
+		}
+		if err != nil {
+			return fmt.Errorf("rle: malformed header %q", line)
+		}
+	}
+	if width < 0 || height < 0 {
+		return fmt.Errorf("rle: header %q must give both x and y", line)
+	}
+	pattern.Width = width
+	pattern.Height = height
+	return nil
+}
+
+// WriteRLE writes the pattern run length encoded, with its rule in the header if it has one.
+func WriteRLE(w io.Writer, pattern Pattern) error {
+	writer := bufio.NewWriter(w)
+	header := fmt.Sprintf("x = %d, y = %d", pattern.Width, pattern.Height)
+	if pattern.Rule != "" {
+		header += ", rule = " + pattern.Rule
+	}
+	_, _ = writer.WriteString(header + "\n")
+
+	grid := make([][]bool, pattern.Height)
+	for i := range grid {
+		grid[i] = make([]bool, pattern.Width)
+	}
+	for _, cell := range pattern.Alive {
+		grid[cell.Y][cell.X] = true
+	}
+
+	//Runs are only written once we know how long they are. Dead cells at the end of a row and
+	//empty rows at the end of the pattern are never written at all.
+	line := ""
+	emit := func(run int, tag byte) {
+		token := string(tag)
+		if run > 1 {
+			token = strconv.Itoa(run) + token
+		}
+		if len(line)+len(token) > rleLineLength {
+			_, _ = writer.WriteString(line + "\n")
+			line = ""
+		}
+		line += token
+	}
+	pendingRows := 0
+	for _, row := range grid {
+		pendingDead := 0
+		for x := 0; x < len(row); {
+			run := 1
+			for x+run < len(row) && row[x+run] == row[x] {
+				run++
+			}
+			if !row[x] {
+				pendingDead = run
+			} else {
+				if pendingRows > 0 {
+					emit(pendingRows, '$')
+					pendingRows = 0
+				}
+				if pendingDead > 0 {
+					emit(pendingDead, 'b')
+					pendingDead = 0
+				}
+				emit(run, 'o')
+			}
+			x += run
+		}
+		pendingRows++
+	}
+	emit(1, '!')
+	_, _ = writer.WriteString(line + "\n")
+	return writer.Flush()
+}
@@ -0,0 +1,22 @@
+package util
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// OnShutdownSignal calls shutDown in the background once the process is interrupted (Ctrl-C) or asked to terminate.
+// shutDown should stop the program neatly. A second signal while it does so exits straight away.
+func OnShutdownSignal(shutDown func()) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		go func() {
+			<-signals
+			os.Exit(1)
+		}()
+		shutDown()
+	}()
+}
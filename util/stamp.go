@@ -0,0 +1,188 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The ways a stamp can combine with the cells already in the world.
+const (
+	// StampOr makes the stamp's cells alive and leaves the rest of the world alone.
+	StampOr = "or"
+	// StampXor flips the world's cells under the stamp's alive cells.
+	StampXor = "xor"
+)
+
+// Stamp places a pattern file in the world when it is loaded.
+type Stamp struct {
+	// Path is the pattern file, in any format we can read.
+	Path string
+	// Offset is where the top left corner of the pattern goes, after it has been reflected and rotated.
+	Offset Cell
+	// Rotation is the number of degrees to turn the pattern clockwise: 0, 90, 180 or 270.
+	Rotation int
+	// Reflect mirrors the pattern left to right before it is rotated.
+	Reflect bool
+	// Mode is StampOr or StampXor. Empty means StampOr.
+	Mode string
+}
+
+// ParseStamp reads a stamp written as the path and offset followed by any options, separated by spaces, e.g.
+//
+//	glider.rle 10,20 rotate=90 flip xor
+//
+// The options are rotate=0, 90, 180 or 270, flip to reflect the pattern, and or or xor for the mode.
+func ParseStamp(spec string) (Stamp, error) {
+	var stamp Stamp
+	fields := strings.Fields(spec)
+	if len(fields) < 2 {
+		return stamp, fmt.Errorf("stamp: %q needs a path and an x,y offset", spec)
+	}
+	stamp.Path = fields[0]
+	if _, err := fmt.Sscanf(fields[1], "%d,%d", &stamp.Offset.X, &stamp.Offset.Y); err != nil {
+		return stamp, fmt.Errorf("stamp: malformed offset %q", fields[1])
+	}
+
+	for _, option := range fields[2:] {
+		switch {
+		case strings.HasPrefix(option, "rotate="):
+			rotation, err := strconv.Atoi(strings.TrimPrefix(option, "rotate="))
+			if err != nil || rotation%90 != 0 || rotation < 0 || rotation >= 360 {
+				return stamp, fmt.Errorf("stamp: rotation must be 0, 90, 180 or 270, not %q", option)
+			}
+			stamp.Rotation = rotation
+		case option == "flip":
+			stamp.Reflect = true
+		case option == StampOr || option == StampXor:
+			stamp.Mode = option
+		default:
+			return stamp, fmt.Errorf("stamp: unknown option %q", option)
+		}
+	}
+	return stamp, nil
+}
+
+// ReadStampFile reads a composition file of one stamp per line, written as ParseStamp expects.
+// Blank lines and lines starting with # are skipped. Relative paths are relative to the composition file.
+func ReadStampFile(path string) ([]Stamp, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var stamps []Stamp
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		stamp, err := ParseStamp(line)
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(stamp.Path) {
+			stamp.Path = filepath.Join(filepath.Dir(path), stamp.Path)
+		}
+		stamps = append(stamps, stamp)
+	}
+	return stamps, scanner.Err()
+}
+
+// Apply reads the stamp's pattern and places it in world.
+func (stamp Stamp) Apply(world [][]byte) error {
+	pattern, err := readStampPattern(stamp.Path)
+	if err != nil {
+		return err
+	}
+	if stamp.Reflect {
+		pattern = pattern.Reflect()
+	}
+	pattern = pattern.Rotate(stamp.Rotation / 90)
+
+	height := len(world)
+	width := 0
+	if height > 0 {
+		width = len(world[0])
+	}
+	if pattern.Width > width || pattern.Height > height {
+		return fmt.Errorf("stamp: %v is %vx%v, which does not fit in a %vx%v world", stamp.Path,
+			pattern.Width, pattern.Height, width, height)
+	}
+
+	switch stamp.Mode {
+	case "", StampOr:
+		pattern.Place(world, stamp.Offset)
+	case StampXor:
+		pattern.Toggle(world, stamp.Offset)
+	default:
+		return fmt.Errorf("stamp: unknown mode %q", stamp.Mode)
+	}
+	return nil
+}
+
+//Reads a pattern to stamp from a file in any format, including a PGM or PBM image
+func readStampPattern(path string) (Pattern, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return Pattern{}, err
+	}
+	if format != FormatPGM {
+		return ReadPatternFile(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Pattern{}, err
+	}
+	defer file.Close()
+	_, image, err := ReadPNM(file, DefaultThreshold)
+	if err != nil {
+		return Pattern{}, err
+	}
+	return PatternFromWorld(image, ""), nil
+}
+
+// Stamps collects stamps from a command line flag that can be given more than once.
+type Stamps []Stamp
+
+func (stamps *Stamps) String() string {
+	if stamps == nil {
+		return ""
+	}
+	specs := make([]string, len(*stamps))
+	for i, stamp := range *stamps {
+		specs[i] = stamp.String()
+	}
+	return strings.Join(specs, "; ")
+}
+
+// Set parses one stamp and adds it to the list.
+func (stamps *Stamps) Set(spec string) error {
+	stamp, err := ParseStamp(spec)
+	if err != nil {
+		return err
+	}
+	*stamps = append(*stamps, stamp)
+	return nil
+}
+
+// String writes the stamp back out as ParseStamp reads it.
+func (stamp Stamp) String() string {
+	spec := fmt.Sprintf("%v %d,%d", stamp.Path, stamp.Offset.X, stamp.Offset.Y)
+	if stamp.Rotation != 0 {
+		spec += " rotate=" + strconv.Itoa(stamp.Rotation)
+	}
+	if stamp.Reflect {
+		spec += " flip"
+	}
+	if stamp.Mode != "" {
+		spec += " " + stamp.Mode
+	}
+	return spec
+}
@@ -0,0 +1,234 @@
+package web
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// finished is the state reported once the game's events have ended, after it has quit or run all its turns.
+const finished = "Finished"
+
+// saveTimeout is how long POST /snapshot waits for the world to be saved.
+const saveTimeout = 10 * time.Second
+
+// commandTimeout is how long a request waits for the game to take its command before giving up.
+const commandTimeout = 2 * time.Second
+
+// status is the body of GET /status, and of the replies to the control requests.
+type status struct {
+	Turn   int        `json:"turn"`
+	Alive  int        `json:"alive"`
+	State  string     `json:"state"`
+	Params gol.Params `json:"params"`
+}
+
+// region is the body of GET /world. Each row of Cells has 'O' for an alive cell and '.' for a dead one,
+// as in the plaintext pattern format.
+type region struct {
+	Turn   int      `json:"turn"`
+	X      int      `json:"x"`
+	Y      int      `json:"y"`
+	Width  int      `json:"width"`
+	Height int      `json:"height"`
+	Cells  []string `json:"cells"`
+}
+
+//Adds the API to the server's routes. The requests that control the game are only served if there is one to control.
+//
+//	GET  /status              the turn, the alive cell count, the state and the parameters of the game
+//	GET  /world?x=&y=&w=&h=   the cells of a region of the world, all of it by default
+//	POST /pause               pauses the game
+//	POST /resume              resumes the game
+//	POST /snapshot            saves the world, as s does, and replies with the saved image
+//	POST /quit                saves the world and stops the game, as q does
+func (s *Server) handleAPI() {
+	s.mux.HandleFunc("/status", s.serveStatus)
+	s.mux.HandleFunc("/world", s.serveWorld)
+	if s.commands == nil {
+		return
+	}
+	s.mux.HandleFunc("/pause", s.control(gol.SetPaused(true)))
+	s.mux.HandleFunc("/resume", s.control(gol.SetPaused(false)))
+	s.mux.HandleFunc("/quit", s.control(gol.KeyPress('q')))
+	s.mux.HandleFunc("/snapshot", s.serveSnapshot)
+}
+
+//Runs look on Run's goroutine, where it can safely read and change the game, and waits for it to finish
+func (s *Server) inspect(look func(g *game)) {
+	done := make(chan bool)
+	s.queries <- func(g *game) {
+		look(g)
+		done <- true
+	}
+	<-done
+}
+
+//Gives the status of the game as it is now
+func (s *Server) status() status {
+	var current status
+	s.inspect(func(g *game) {
+		current = status{Turn: g.turn, Alive: g.alive, State: g.state, Params: s.params}
+	})
+	return current
+}
+
+func (s *Server) serveStatus(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+//Gives a handler that sends the game command, and replies with the status of the game as it was when sent.
+//The game acts on commands between turns, so the reply is 202 Accepted and the change shows in later statuses.
+func (s *Server) control(command gol.Command) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		current := s.status()
+		if current.State == finished {
+			http.Error(w, "The game has finished", http.StatusConflict)
+			return
+		}
+		if !s.sendCommand(w, r, command) {
+			return
+		}
+		writeJSON(w, http.StatusAccepted, current)
+	}
+}
+
+//Sends the game a command, giving up if the request is cancelled or the game doesn't take it in time.
+//Reports whether it was sent, replying 503 Service Unavailable if it timed out.
+func (s *Server) sendCommand(w http.ResponseWriter, r *http.Request, command gol.Command) bool {
+	select {
+	case s.commands <- command:
+		return true
+	case <-r.Context().Done():
+		return false
+	case <-time.After(commandTimeout):
+		http.Error(w, "The game didn't take the command in time", http.StatusServiceUnavailable)
+		return false
+	}
+}
+
+//Saves the world as s does, waits for the file to be written, then replies with it
+func (s *Server) serveSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	//The request waits for the next save from when it was made, so it must be waiting before s is pressed
+	saved := make(chan gol.ImageOutputComplete, 1)
+	var state string
+	s.inspect(func(g *game) {
+		state = g.state
+		if state != finished {
+			g.saves = append(g.saves, saved)
+		}
+	})
+	if state == finished {
+		http.Error(w, "The game has finished", http.StatusConflict)
+		return
+	}
+	if !s.sendCommand(w, r, gol.KeyPress('s')) {
+		return
+	}
+
+	select {
+	case event := <-saved:
+		format := s.params.OutputFormat
+		if format == "" {
+			format = util.FormatPGM
+		}
+		image, err := ioutil.ReadFile("out/" + event.Filename + util.Extension(format))
+		if err != nil {
+			http.Error(w, "The saved image can't be read: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType(format))
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+event.Filename+util.Extension(format)+"\"")
+		w.Header().Set("X-Turn", strconv.Itoa(event.CompletedTurns))
+		_, _ = w.Write(image)
+	case <-r.Context().Done():
+	case <-time.After(saveTimeout):
+		http.Error(w, "The world wasn't saved in time", http.StatusGatewayTimeout)
+	}
+}
+
+//Replies with the cells of the region of the world asked for. The region can't wrap around the edges.
+func (s *Server) serveWorld(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	query := r.URL.Query()
+	var bounds [4]int
+	defaults := [4]int{0, 0, s.width, s.height}
+	for i, name := range []string{"x", "y", "w", "h"} {
+		bounds[i] = defaults[i]
+		if value := query.Get(name); value != "" {
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, name+" must be a whole number", http.StatusBadRequest)
+				return
+			}
+			bounds[i] = number
+		}
+	}
+	x, y, width, height := bounds[0], bounds[1], bounds[2], bounds[3]
+	if x < 0 || y < 0 || width < 1 || height < 1 || x+width > s.width || y+height > s.height {
+		http.Error(w, "The region must be inside the "+strconv.Itoa(s.width)+"x"+strconv.Itoa(s.height)+" world",
+			http.StatusBadRequest)
+		return
+	}
+
+	reply := region{X: x, Y: y, Width: width, Height: height}
+	s.inspect(func(g *game) {
+		reply.Turn = g.turn
+		for row := y; row < y+height; row++ {
+			var cells strings.Builder
+			for column := x; column < x+width; column++ {
+				if g.world[row*s.width+column] {
+					cells.WriteByte('O')
+				} else {
+					cells.WriteByte('.')
+				}
+			}
+			reply.Cells = append(reply.Cells, cells.String())
+		}
+	})
+	writeJSON(w, http.StatusOK, reply)
+}
+
+//Gives the media type of saved images of the format. The pattern formats have none registered.
+func contentType(format string) string {
+	switch format {
+	case util.FormatPGM:
+		return "image/x-portable-graymap"
+	case util.FormatPNG:
+		return "image/png"
+	}
+	return "application/octet-stream"
+}
+
+//Reports whether the request uses the method, replying 405 Method Not Allowed if it doesn't
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	http.Error(w, "Use "+method, http.StatusMethodNotAllowed)
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
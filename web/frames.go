@@ -0,0 +1,53 @@
+package web
+
+import (
+	"encoding/binary"
+)
+
+// The first byte of each binary message, saying what follows.
+const (
+	// keyframeMessage is followed by the turn, the alive cell count, the width and the height as little endian
+	// uint32s, then the whole world with one bit per cell, row by row, lowest bit first.
+	keyframeMessage = 1
+	// deltaMessage is followed by the turn and the alive cell count as little endian uint32s, then the cells that have
+	// flipped since the last message. Each is given as a varint of how far its index is past the last one.
+	deltaMessage = 2
+)
+
+//Encodes the whole world as a keyframe message
+func encodeKeyframe(world []bool, width, height, turn, alive int) []byte {
+	message := make([]byte, 17+(len(world)+7)/8)
+	message[0] = keyframeMessage
+	binary.LittleEndian.PutUint32(message[1:], uint32(turn))
+	binary.LittleEndian.PutUint32(message[5:], uint32(alive))
+	binary.LittleEndian.PutUint32(message[9:], uint32(width))
+	binary.LittleEndian.PutUint32(message[13:], uint32(height))
+	bits := message[17:]
+	for i, cell := range world {
+		if cell {
+			bits[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return message
+}
+
+//Encodes the cells that differ between sent and world as a delta message, and brings sent up to date.
+//Reports false, with no message, when nothing has changed.
+func encodeDelta(sent, world []bool, turn, alive int) ([]byte, bool) {
+	message := make([]byte, 9, 64)
+	message[0] = deltaMessage
+	binary.LittleEndian.PutUint32(message[1:], uint32(turn))
+	binary.LittleEndian.PutUint32(message[5:], uint32(alive))
+
+	var varint [binary.MaxVarintLen64]byte
+	last := -1
+	for i := range world {
+		if world[i] != sent[i] {
+			n := binary.PutUvarint(varint[:], uint64(i-last))
+			message = append(message, varint[:n]...)
+			sent[i] = world[i]
+			last = i
+		}
+	}
+	return message, last >= 0
+}
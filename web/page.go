@@ -0,0 +1,98 @@
+package web
+
+// page is the viewer served at /. It draws the world on a canvas from the keyframes and deltas sent on /ws,
+// and reconnects if the game is restarted.
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Game of Life</title>
+<style>
+  body { background: #202020; color: #e0e0e0; font-family: monospace; margin: 1em; }
+  canvas { image-rendering: pixelated; image-rendering: crisp-edges; background: #000; display: block; margin-top: 0.5em; }
+</style>
+</head>
+<body>
+<div id="status">Connecting</div>
+<div id="message"></div>
+<canvas id="world" width="1" height="1"></canvas>
+<script>
+const canvas = document.getElementById('world');
+const context = canvas.getContext('2d');
+const statusLine = document.getElementById('status');
+const messageLine = document.getElementById('message');
+let width = 0, height = 0, cells = null, image = null;
+
+function setCell(index, alive) {
+  cells[index] = alive;
+  const value = alive ? 255 : 0;
+  image.data[index * 4] = value;
+  image.data[index * 4 + 1] = value;
+  image.data[index * 4 + 2] = value;
+  image.data[index * 4 + 3] = 255;
+}
+
+function keyframe(view, bytes) {
+  width = view.getUint32(9, true);
+  height = view.getUint32(13, true);
+  if (canvas.width !== width || canvas.height !== height) {
+    canvas.width = width;
+    canvas.height = height;
+    const scale = Math.max(1, Math.floor(768 / Math.max(width, height)));
+    canvas.style.width = (width * scale) + 'px';
+    canvas.style.height = (height * scale) + 'px';
+  }
+  cells = new Uint8Array(width * height);
+  image = context.createImageData(width, height);
+  for (let i = 0; i < width * height; i++) {
+    setCell(i, (bytes[17 + (i >> 3)] >> (i & 7)) & 1);
+  }
+}
+
+function delta(view, bytes) {
+  let offset = 9, index = -1;
+  while (offset < bytes.length) {
+    let gap = 0, shift = 0, b;
+    do {
+      b = bytes[offset++];
+      gap += (b & 0x7f) * Math.pow(2, shift);
+      shift += 7;
+    } while (b & 0x80);
+    index += gap;
+    setCell(index, cells[index] ^ 1);
+  }
+}
+
+function connect() {
+  const socket = new WebSocket((location.protocol === 'https:' ? 'wss://' : 'ws://') + location.host + '/ws');
+  socket.binaryType = 'arraybuffer';
+  socket.onmessage = function (event) {
+    if (typeof event.data === 'string') {
+      messageLine.textContent = event.data;
+      return;
+    }
+    const view = new DataView(event.data);
+    const bytes = new Uint8Array(event.data);
+    const kind = view.getUint8(0);
+    if (kind === 1) {
+      keyframe(view, bytes);
+    } else if (kind === 2 && cells !== null) {
+      delta(view, bytes);
+    } else {
+      return;
+    }
+    context.putImageData(image, 0, 0);
+    statusLine.textContent = 'Turn ' + view.getUint32(1, true) + '  Alive ' + view.getUint32(5, true) +
+      '  ' + width + 'x' + height;
+  };
+  socket.onclose = function () {
+    statusLine.textContent = 'Disconnected, trying again';
+    setTimeout(connect, 1000);
+  };
+}
+
+connect();
+</script>
+</body>
+</html>
+`
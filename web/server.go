@@ -0,0 +1,268 @@
+package web
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/metrics"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// frameInterval is how often the changes to the world are sent to viewers, however fast the game runs.
+// Flips between frames are merged, so a cell that flips twice isn't sent at all.
+const frameInterval = time.Second / 20
+
+// keyframeInterval is how often every viewer is sent the whole world, in case a delta went astray.
+const keyframeInterval = 10 * time.Second
+
+// viewerBuffer is how many messages can wait for a viewer. A viewer that falls this far behind misses deltas,
+// and is sent a keyframe once it catches up.
+const viewerBuffer = 16
+
+// Server serves a page showing the game live in the browser, and the WebSocket that the page is sent the world on.
+// Any number of browsers can watch at once. It also serves the REST API in api.go for scripts to drive the game,
+// and the metrics at /metrics.
+type Server struct {
+	width, height int
+	params        gol.Params
+	mux           *http.ServeMux
+	join          chan *viewer
+	leave         chan *viewer
+	//commands is where the API sends the game commands, or nil if the game can only be watched
+	commands chan<- gol.Command
+	queries  chan func(*game)
+}
+
+// game is what the server knows of the game from its events. It belongs to Run, and the API reaches it through
+// inspect.
+type game struct {
+	world       []bool
+	turn, alive int
+	state       string
+	//saves are the API requests waiting for the next image to be saved
+	saves []chan<- gol.ImageOutputComplete
+}
+
+// viewer is one browser watching the game.
+type viewer struct {
+	send chan message
+	// stale is set when a message had to be dropped, so the viewer needs a keyframe.
+	stale bool
+}
+
+// message is a WebSocket message waiting to be sent to a viewer.
+type message struct {
+	opcode  byte
+	payload []byte
+}
+
+// NewServer makes a Server for the game run with p. Run must be called to pass it events.
+// The API controls the game by sending on commands. If commands is nil the game can be watched but not controlled.
+func NewServer(p gol.Params, commands chan<- gol.Command) *Server {
+	server := &Server{
+		width:    p.ImageWidth,
+		height:   p.ImageHeight,
+		params:   p,
+		mux:      http.NewServeMux(),
+		join:     make(chan *viewer),
+		leave:    make(chan *viewer),
+		commands: commands,
+		queries:  make(chan func(*game)),
+	}
+	server.mux.HandleFunc("/", server.servePage)
+	server.mux.HandleFunc("/ws", server.serveWebSocket)
+	server.mux.Handle("/metrics", metrics.Handler())
+	server.handleAPI()
+	return server
+}
+
+// Serve listens on addr, such as ":8080", and serves the game to browsers in the background.
+// Every event from in is passed through to out, and the game is controlled through commands.
+// It returns the address being listened on.
+func Serve(addr string, p gol.Params, commands chan<- gol.Command, in <-chan gol.Event, out chan<- gol.Event) string {
+	listener, err := net.Listen("tcp", addr)
+	util.Check(err)
+	server := NewServer(p, commands)
+	go server.Run(in, out)
+	go func() {
+		util.Check(http.Serve(listener, server))
+	}()
+	return listener.Addr().String()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) servePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprint(w, page)
+}
+
+//Turns the request into a WebSocket and sends the viewer's messages on it until it closes
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, readWriter, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	v := &viewer{send: make(chan message, viewerBuffer)}
+	s.join <- v
+	go s.readFrom(v, readWriter.Reader)
+
+	var failed = false
+	for m := range v.send {
+		if failed {
+			continue
+		}
+		if writeFrame(readWriter.Writer, m.opcode, m.payload) != nil || readWriter.Flush() != nil {
+			//Closing the connection stops readFrom, which makes the viewer leave and closes send
+			failed = true
+			_ = conn.Close()
+		}
+	}
+	if !failed {
+		_ = writeFrame(readWriter.Writer, opClose, nil)
+		_ = readWriter.Flush()
+		_ = conn.Close()
+	}
+}
+
+//Reads from a viewer until it closes the WebSocket or the connection fails, then makes it leave.
+//Browsers only send close frames, so anything else is ignored.
+func (s *Server) readFrom(v *viewer, reader *bufio.Reader) {
+	for {
+		opcode, _, err := readFrame(reader)
+		if err != nil || opcode == opClose {
+			break
+		}
+	}
+	s.leave <- v
+}
+
+// Run passes every event from in through to out, keeping its own copy of the world up to date from them and
+// sending the changes on to the viewers. It carries on serving viewers and the API the final world after in is
+// closed.
+func (s *Server) Run(in <-chan gol.Event, out chan<- gol.Event) {
+	g := &game{world: make([]bool, s.width*s.height), state: gol.Executing.String()}
+	//sent is the world as the viewers last saw it, so only the cells that differ need sending
+	sent := make([]bool, s.width*s.height)
+	sentTurn, sentAlive := 0, 0
+	viewers := make(map[*viewer]bool)
+
+	//Queues a message for a viewer, or marks it as needing a keyframe if it has fallen too far behind
+	send := func(v *viewer, m message) {
+		select {
+		case v.send <- m:
+			v.stale = false
+		default:
+			v.stale = true
+		}
+	}
+	keyframe := func() message {
+		return message{opBinary, encodeKeyframe(sent, s.width, s.height, sentTurn, sentAlive)}
+	}
+	//Sends the message to every viewer, or a keyframe instead to the viewers that missed a message
+	broadcast := func(m message) {
+		var catchUp message
+		for v := range viewers {
+			if !v.stale {
+				send(v, m)
+				continue
+			}
+			if catchUp.payload == nil {
+				catchUp = keyframe()
+			}
+			send(v, catchUp)
+		}
+	}
+	//Sends the cells that have changed since the last frame, if anything has
+	sendFrame := func() {
+		delta, changed := encodeDelta(sent, g.world, g.turn, g.alive)
+		if changed || g.turn != sentTurn {
+			sentTurn, sentAlive = g.turn, g.alive
+			broadcast(message{opBinary, delta})
+		}
+	}
+	//Shows the event's message on the viewers' pages, if it has one
+	announce := func(event gol.Event) {
+		if text := event.String(); text != "" {
+			broadcast(message{opText, []byte(fmt.Sprintf("Turn %v: %v", event.GetCompletedTurns(), text))})
+		}
+	}
+
+	frames := time.NewTicker(frameInterval)
+	defer frames.Stop()
+	keyframes := time.NewTicker(keyframeInterval)
+	defer keyframes.Stop()
+	for {
+		select {
+		case event, ok := <-in:
+			if !ok {
+				sendFrame()
+				g.state = finished
+				close(out)
+				in = nil
+				continue
+			}
+			switch e := event.(type) {
+			case gol.CellFlipped:
+				i := e.Cell.Y*s.width + e.Cell.X
+				g.world[i] = !g.world[i]
+				if g.world[i] {
+					g.alive++
+				} else {
+					g.alive--
+				}
+			case gol.TurnComplete:
+				g.turn = e.CompletedTurns
+			case gol.WorldState:
+				//Whoever asked for the world found it out of step, so the copy here is made to match it too
+				for i := range g.world {
+					g.world[i] = false
+				}
+				for _, cell := range e.Alive {
+					g.world[cell.Y*s.width+cell.X] = true
+				}
+				g.alive = len(e.Alive)
+			case gol.FinalTurnComplete:
+				g.turn = e.CompletedTurns
+				sendFrame()
+			case gol.ImageOutputComplete:
+				for _, save := range g.saves {
+					save <- e
+				}
+				g.saves = nil
+				announce(event)
+			case gol.StateChange:
+				g.state = e.NewState.String()
+				announce(event)
+			default:
+				announce(event)
+			}
+			out <- event
+		case <-frames.C:
+			sendFrame()
+		case <-keyframes.C:
+			m := keyframe()
+			for v := range viewers {
+				send(v, m)
+			}
+		case query := <-s.queries:
+			query(g)
+		case v := <-s.join:
+			viewers[v] = true
+			send(v, keyframe())
+		case v := <-s.leave:
+			delete(viewers, v)
+			close(v.send)
+		}
+	}
+}
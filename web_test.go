@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+	"uk.ac.bris.cs/gameoflife/web"
+)
+
+// TestWeb watches the 16x16 image for 100 turns from two WebSocket viewers at once, and checks that both end up
+// with the expected world after applying the keyframes and deltas they are sent.
+func TestWeb(t *testing.T) {
+	p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 16, ImageHeight: 16}
+	server := web.NewServer(p, nil)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	in := make(chan gol.Event, 1000)
+	out := make(chan gol.Event, 1000)
+	go server.Run(in, out)
+
+	var viewers []*testViewer
+	for i := 0; i < 2; i++ {
+		viewers = append(viewers, dialViewer(t, strings.TrimPrefix(httpServer.URL, "http://")))
+	}
+	for _, v := range viewers {
+		v.readMessage(t)
+	}
+
+	go gol.Run(p, in, nil)
+	var final gol.FinalTurnComplete
+	for event := range out {
+		if e, ok := event.(gol.FinalTurnComplete); ok {
+			final = e
+		}
+	}
+	if final.CompletedTurns != 100 {
+		t.Fatalf("Expected the run to finish at turn 100, got %v", final.CompletedTurns)
+	}
+
+	expected := readAliveCells("check/images/16x16x100.pgm", 16, 16)
+	for i, v := range viewers {
+		for v.turn < 100 {
+			v.readMessage(t)
+		}
+		if !assertEqualBoard(t, v.aliveCells(), expected, p) {
+			t.Errorf("Viewer %v has the wrong world", i)
+		}
+	}
+}
+
+// testViewer is a WebSocket client that keeps its own world up to date from the messages it is sent.
+type testViewer struct {
+	conn          net.Conn
+	reader        *bufio.Reader
+	width, height int
+	turn          int
+	world         []bool
+}
+
+func dialViewer(t *testing.T, address string) *testViewer {
+	conn, err := net.Dial("tcp", address)
+	util.Check(err)
+	_, err = io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: "+address+"\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	util.Check(err)
+	reader := bufio.NewReader(conn)
+	status, err := reader.ReadString('\n')
+	util.Check(err)
+	if !strings.Contains(status, "101") {
+		t.Fatalf("Expected 101 Switching Protocols, got %v", status)
+	}
+	//The accept key for this client key is given in RFC 6455
+	var accepted bool
+	for {
+		line, err := reader.ReadString('\n')
+		util.Check(err)
+		if line == "\r\n" {
+			break
+		}
+		accepted = accepted || strings.Contains(line, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=")
+	}
+	if !accepted {
+		t.Fatal("Expected the handshake to be accepted with the key from RFC 6455")
+	}
+	return &testViewer{conn: conn, reader: reader}
+}
+
+//Reads the next binary message from the server and applies it to the viewer's world
+func (v *testViewer) readMessage(t *testing.T) {
+	util.Check(v.conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	for {
+		var header [2]byte
+		_, err := io.ReadFull(v.reader, header[:])
+		util.Check(err)
+		length := uint64(header[1] & 0x7F)
+		if length == 126 {
+			var extended [2]byte
+			_, err = io.ReadFull(v.reader, extended[:])
+			util.Check(err)
+			length = uint64(binary.BigEndian.Uint16(extended[:]))
+		} else if length == 127 {
+			var extended [8]byte
+			_, err = io.ReadFull(v.reader, extended[:])
+			util.Check(err)
+			length = binary.BigEndian.Uint64(extended[:])
+		}
+		payload := make([]byte, length)
+		_, err = io.ReadFull(v.reader, payload)
+		util.Check(err)
+		if header[0]&0x0F != 0x2 {
+			continue
+		}
+
+		v.turn = int(binary.LittleEndian.Uint32(payload[1:]))
+		switch payload[0] {
+		case 1:
+			v.width = int(binary.LittleEndian.Uint32(payload[9:]))
+			v.height = int(binary.LittleEndian.Uint32(payload[13:]))
+			v.world = make([]bool, v.width*v.height)
+			for i := range v.world {
+				v.world[i] = payload[17+i/8]&(1<<uint(i%8)) != 0
+			}
+		case 2:
+			index := -1
+			for rest := payload[9:]; len(rest) > 0; {
+				gap, n := binary.Uvarint(rest)
+				rest = rest[n:]
+				index += int(gap)
+				v.world[index] = !v.world[index]
+			}
+		default:
+			t.Fatalf("Unexpected message type %v", payload[0])
+		}
+		return
+	}
+}
+
+func (v *testViewer) aliveCells() []util.Cell {
+	var cells []util.Cell
+	for i, alive := range v.world {
+		if alive {
+			cells = append(cells, util.Cell{X: i % v.width, Y: i / v.width})
+		}
+	}
+	return cells
+}
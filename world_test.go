@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/eventlog"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestWorldState asks the engine for the world with RequestWorld as the 16x16 image runs, then a paused replay of it,
+// checking each WorldState holds the cells the CellFlipped events before it make alive.
+func TestWorldState(t *testing.T) {
+	p := gol.Params{Turns: 100000000, Threads: 4, ImageWidth: 16, ImageHeight: 16}
+	events := make(chan gol.Event, 1000)
+	commands := make(chan gol.Command, 2)
+	go gol.RunCommands(p, events, commands)
+
+	world := make(map[util.Cell]bool)
+	answers := 0
+	quitTurn := -1
+	commands <- gol.RequestWorld{}
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			world[e.Cell] = !world[e.Cell]
+		case gol.WorldState:
+			assertWorldState(t, e, world)
+			answers++
+			if answers < 3 {
+				commands <- gol.RequestWorld{}
+			} else {
+				commands <- gol.KeyPress('q')
+			}
+		case gol.StateChange:
+			if e.NewState == gol.Quitting {
+				quitTurn = e.CompletedTurns
+			}
+		}
+	}
+	if answers != 3 || quitTurn < 0 {
+		t.Fatalf("Expected 3 answers before quitting, got %v", answers)
+	}
+	filename := "out/16x16x" + strconv.Itoa(quitTurn)
+	os.Remove(filename + ".pgm")
+	os.Remove(filename + ".checkpoint")
+
+	dir, err := ioutil.TempDir("", "world")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "run.gollog")
+	p.Turns = 20
+	engineEvents := make(chan gol.Event, 1000)
+	recorded := make(chan gol.Event, 1000)
+	go eventlog.Record(path, p, engineEvents, recorded)
+	go gol.Run(p, engineEvents, nil)
+	for range recorded {
+	}
+	defer os.Remove("out/16x16x20.pgm")
+
+	replayed := make(chan gol.Event)
+	replayCommands := make(chan gol.Command)
+	go eventlog.Replay(path, 1, 0, replayed, replayCommands)
+	world = make(map[util.Cell]bool)
+	answered := false
+	for event := range replayed {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			world[e.Cell] = !world[e.Cell]
+		case gol.TurnComplete:
+			if e.CompletedTurns == 5 {
+				replayCommands <- gol.SetPaused(true)
+			}
+		case gol.StateChange:
+			if e.NewState == gol.Paused {
+				replayCommands <- gol.RequestWorld{}
+			}
+		case gol.WorldState:
+			if e.CompletedTurns != 5 {
+				t.Errorf("Expected the paused replay's world at turn 5, got turn %v", e.CompletedTurns)
+			}
+			assertWorldState(t, e, world)
+			answered = true
+			replayCommands <- gol.KeyPress('q')
+		}
+	}
+	if !answered {
+		t.Error("Expected the replay to answer RequestWorld")
+	}
+}
+
+//Checks the cells in a WorldState are exactly those alive in world
+func assertWorldState(t *testing.T, state gol.WorldState, world map[util.Cell]bool) {
+	alive := make(map[util.Cell]bool)
+	for cell, isAlive := range world {
+		if isAlive {
+			alive[cell] = true
+		}
+	}
+	sent := make(map[util.Cell]bool)
+	for _, cell := range state.Alive {
+		sent[cell] = true
+	}
+	if !reflect.DeepEqual(sent, alive) {
+		t.Errorf("Expected the %v cells alive at turn %v, got %v", len(alive), state.CompletedTurns, len(sent))
+	}
+}